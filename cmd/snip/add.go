@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/HrodWolfS/snipster/internal/clip"
+	"github.com/HrodWolfS/snipster/internal/model"
+	"github.com/HrodWolfS/snipster/internal/secrets"
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// runAdd creates a snippet from flags, reading its content from stdin or, with
+// -from-clipboard, from the clipboard. Without -title/-category, clipboard
+// content opens the TUI Create modal instead.
+//
+//	echo 'docker ps -a' | snip add -title "List containers" -category docker -tags ps
+//	snip add --from-clipboard
+func runAdd(args []string) int {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	title := fs.String("title", "", "snippet title (required)")
+	category := fs.String("category", "", "category path, e.g. backend/db (required)")
+	tags := fs.String("tags", "", "comma-separated tags")
+	lang := fs.String("lang", "", "language, e.g. bash, go, sql")
+	aliases := fs.String("aliases", "", "comma-separated short names, e.g. dkp")
+	force := fs.Bool("force", false, "save even if secrets are detected")
+	redact := fs.Bool("redact", false, "replace detected secrets with placeholders")
+	sourceURL := fs.String("source", "", "URL the snippet comes from")
+	author := fs.String("author", "", "author of the snippet")
+	license := fs.String("license", "", "license of the snippet, e.g. MIT")
+	fromClipboard := fs.Bool("from-clipboard", false, "take the content from the clipboard")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	missing := strings.TrimSpace(*title) == "" || strings.TrimSpace(*category) == ""
+	if missing && !*fromClipboard {
+		fmt.Fprintln(os.Stderr, "snip add: -title and -category are required")
+		fs.Usage()
+		return 2
+	}
+	if err := snippets.ValidateSourceURL(*sourceURL); err != nil {
+		fmt.Fprintln(os.Stderr, "snip add:", err)
+		return 2
+	}
+	var content, source string
+	if *fromClipboard {
+		text, err := clip.Paste(clip.MethodFromEnv())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snip add:", err)
+			return 1
+		}
+		content, source = text, "clipboard"
+	} else {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snip add:", err)
+			return 1
+		}
+		content, source = string(b), "stdin"
+	}
+	if strings.TrimSpace(content) == "" {
+		fmt.Fprintf(os.Stderr, "snip add: empty content on %s\n", source)
+		return 2
+	}
+	s := snippets.Snippet{
+		Title:     strings.TrimSpace(*title),
+		Category:  strings.Trim(strings.TrimSpace(*category), "/"),
+		Language:  strings.TrimSpace(*lang),
+		Tags:      splitList(*tags),
+		Aliases:   splitList(*aliases),
+		Content:   content,
+		SourceURL: strings.TrimSpace(*sourceURL),
+		Author:    strings.TrimSpace(*author),
+		License:   strings.TrimSpace(*license),
+	}
+	if missing {
+		// The modal runs its own secret scan on save.
+		return runAddTUI(s)
+	}
+	if s.Language == "" {
+		s.Language = snippets.GuessLanguage(content)
+	}
+
+	if mode := secrets.ModeFromEnv(); mode != secrets.ModeOff {
+		if found := secrets.Scan(content); len(found) > 0 {
+			printFindings(os.Stderr, "<"+source+">", found)
+			switch {
+			case *redact:
+				s.Content = secrets.Redact(content, found)
+				fmt.Fprintln(os.Stderr, "snip add: secrets replaced with placeholders")
+			case mode == secrets.ModeBlock && !*force:
+				fmt.Fprintln(os.Stderr, "snip add: refusing to save possible secrets (use -redact or -force)")
+				return 1
+			}
+		}
+	}
+
+	repo, _, _ := openRepo()
+	s, err := repo.Create(s)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip add:", err)
+		return 1
+	}
+	fmt.Println(s.Path)
+	return 0
+}
+
+// runAddTUI opens the TUI on the Create modal, pre-filled with s.
+func runAddTUI(s snippets.Snippet) int {
+	repo, dataDir, all := openRepo()
+	m := model.New(appContext{repo: repo, dataDir: dataDir}, all)
+	m.PrefillCreate(s)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if _, err := tea.NewProgram(m, tea.WithContext(ctx)).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "snip add:", err)
+		return 1
+	}
+	return 0
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func printFindings(w io.Writer, name string, found []secrets.Finding) {
+	for _, f := range found {
+		at := name
+		if f.Where != "" {
+			at += " (" + f.Where + ")"
+		}
+		fmt.Fprintf(w, "%s:%d: %s: %s\n", at, f.Line, f.Kind, f.Masked())
+	}
+}
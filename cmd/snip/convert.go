@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// runConvert migrates snippet files between the JSON and Markdown formats.
+//
+//	snip convert -to md              # whole library
+//	snip convert -to json fetch-users
+func runConvert(args []string) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	to := fs.String("to", "", "target format: json or md")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	f, ok := snippets.ParseFormat(*to)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "snip convert: -to must be json or md")
+		return 2
+	}
+	refs := fs.Args()
+
+	repo, _, all := openRepo()
+	status, n := 0, 0
+	for _, s := range all {
+		if len(refs) > 0 && !slices.ContainsFunc(refs, s.Matches) {
+			continue
+		}
+		out, err := repo.Convert(s, f)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snip convert:", err)
+			status = 1
+			continue
+		}
+		if out.Path != s.Path {
+			fmt.Printf("%s -> %s\n", s.Path, out.Path)
+			n++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d snippet(s) converted to %s\n", n, f)
+	return status
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// runDedupe reports duplicate and near-duplicate snippets. Exit code 1 when some are found.
+func runDedupe(args []string) int {
+	fs := flag.NewFlagSet("dedupe", flag.ContinueOnError)
+	threshold := fs.Float64("threshold", snippets.DefaultSimilarity, "minimum similarity (0-1) of near-duplicates")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	_, _, all := openRepo()
+	dups := snippets.FindDuplicates(all, *threshold)
+	for _, d := range dups {
+		kind := "similar"
+		if d.Exact {
+			kind = "exact"
+		}
+		fmt.Printf("%3.0f%% %-7s %s  %s\n", d.Similarity*100, kind, d.A.Path, d.B.Path)
+	}
+	if len(dups) > 0 {
+		fmt.Fprintf(os.Stderr, "%d duplicate pair(s) in %d snippets; merge them with M in the TUI\n", len(dups), len(all))
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "no duplicates found in %d snippets\n", len(all))
+	return 0
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// runDoctor checks the library health. With -fix, fixable issues are repaired;
+// -dry-run prints the repairs as a diff instead. -migrate only rewrites files
+// from older schema versions.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	doMigrate := fs.Bool("migrate", false, "rewrite snippet files from older schema versions")
+	doFix := fs.Bool("fix", false, "repair fixable issues")
+	dryRun := fs.Bool("dry-run", false, "show the repairs as a diff without applying them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	dataDir, err := ensureDataDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip doctor:", err)
+		return 1
+	}
+	repo := snippets.NewRepo(dataDir)
+	if *doMigrate {
+		return runMigrate(repo, *dryRun)
+	}
+
+	rep, err := repo.Doctor()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip doctor:", err)
+		return 1
+	}
+	failing := 0
+	for _, is := range rep.Issues {
+		mark := ""
+		if is.Fixable {
+			mark = " (fixable)"
+		}
+		fmt.Printf("%-7s %s: %s: %s%s\n", strings.ToUpper(is.Severity.String()), is.Path, is.Code, is.Message, mark)
+		if is.Severity >= snippets.SeverityWarning && !(is.Fixable && *doFix && !*dryRun) {
+			failing++
+		}
+	}
+
+	switch {
+	case *dryRun:
+		for _, f := range rep.Fixes {
+			fmt.Printf("\n# %s\n%s", strings.Join(f.Reasons, ", "), f.Diff())
+		}
+	case *doFix:
+		for _, f := range rep.Fixes {
+			if err := f.Apply(); err != nil {
+				fmt.Fprintln(os.Stderr, "snip doctor:", err)
+				return 1
+			}
+			fmt.Printf("fixed %s (%s)\n", f.Path, strings.Join(f.Reasons, ", "))
+		}
+	case len(rep.Fixes) > 0:
+		fmt.Fprintf(os.Stderr, "%d fix(es) available; preview with snip doctor -dry-run, apply with snip doctor -fix\n", len(rep.Fixes))
+	}
+	if len(rep.Issues) == 0 {
+		fmt.Println("library is healthy")
+	}
+	if failing > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runMigrate rewrites files from older schema versions, or only lists them
+// with dryRun.
+func runMigrate(repo *snippets.Repo, dryRun bool) int {
+	paths, err := repo.Migrate(dryRun)
+	for _, p := range paths {
+		if dryRun {
+			fmt.Printf("would migrate %s\n", p)
+		} else {
+			fmt.Printf("migrated %s\n", p)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip doctor:", err)
+		return 1
+	}
+	return 0
+}
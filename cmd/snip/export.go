@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/site"
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+const exportUsage = "usage: snip export -html <dir>\n       snip export -markdown [-o dir] [-order title|created|usage] [category...]"
+
+// runExport writes the library in a publishable form: a static HTML site, or
+// one Markdown cheat sheet per category.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	html := fs.String("html", "", "write a static HTML site into this directory")
+	markdown := fs.Bool("markdown", false, "write one Markdown cheat sheet per category")
+	out := fs.String("o", "", "directory of the Markdown cheat sheets (default: stdout)")
+	order := fs.String("order", snippets.OrderTitle, "order of the snippets in a cheat sheet: title, created or usage")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	switch {
+	case *html != "" && !*markdown && fs.NArg() == 0:
+		return exportHTML(*html)
+	case *markdown && *html == "":
+		return exportMarkdown(*out, *order, fs.Args())
+	}
+	fmt.Fprintln(os.Stderr, exportUsage)
+	return 2
+}
+
+func exportHTML(dir string) int {
+	_, _, all := openRepo()
+	res, err := site.Build(all, dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip export:", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d snippet pages to %s\n", res.Snippets, dir)
+	if res.Skipped > 0 {
+		fmt.Fprintf(os.Stderr, "%d encrypted snippets left out\n", res.Skipped)
+	}
+	if res.Invalid > 0 {
+		fmt.Fprintf(os.Stderr, "%d snippets with an invalid category left out\n", res.Invalid)
+	}
+	return 0
+}
+
+// exportMarkdown writes the cheat sheets of the given categories and their
+// subcategories (all of them by default), to dir/<category>.md or stdout.
+func exportMarkdown(dir, order string, cats []string) int {
+	repo, _, all := openRepo()
+	usage, err := repo.LoadUsage()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip export: usage counts unavailable:", err)
+	}
+	byCat := map[string][]snippets.Snippet{}
+	skipped, invalid := 0, 0
+	for _, s := range all {
+		if !underAny(s.Category, cats) {
+			continue
+		}
+		if s.Encrypted {
+			skipped++
+			continue
+		}
+		// The category names the output file: keep it inside dir.
+		if snippets.ValidateCategory(s.Category) != nil {
+			invalid++
+			continue
+		}
+		byCat[s.Category] = append(byCat[s.Category], s)
+	}
+	if len(byCat) == 0 {
+		fmt.Fprintln(os.Stderr, "snip export: no snippets to export")
+		return 1
+	}
+	names := make([]string, 0, len(byCat))
+	for c := range byCat {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+	for i, c := range names {
+		if err := snippets.SortBy(byCat[c], order, usage); err != nil {
+			fmt.Fprintln(os.Stderr, "snip export:", err)
+			return 2
+		}
+		doc := snippets.Cheatsheet(c, byCat[c])
+		if dir == "" {
+			if i > 0 {
+				fmt.Println()
+			}
+			os.Stdout.Write(doc)
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(c)+".md")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			err = os.WriteFile(path, doc, 0o644)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snip export:", err)
+			return 1
+		}
+		fmt.Fprintln(os.Stderr, path)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "%d encrypted snippets left out\n", skipped)
+	}
+	if invalid > 0 {
+		fmt.Fprintf(os.Stderr, "%d snippets with an invalid category left out\n", invalid)
+	}
+	return 0
+}
+
+// underAny reports whether category is one of cats or below one of them; no
+// cats means every category.
+func underAny(category string, cats []string) bool {
+	if len(cats) == 0 {
+		return true
+	}
+	for _, c := range cats {
+		c = strings.Trim(c, "/")
+		if category == c || strings.HasPrefix(category, c+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+	"github.com/HrodWolfS/snipster/internal/vault"
+)
+
+// runGet prints the content of the snippet with the given ID or file slug, decrypting it if needed.
+func runGet(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: snip get <id>")
+		return 2
+	}
+	repo, _, all := openRepo()
+	s, ok := snippets.Find(all, args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "snip get: no snippet %q\n", args[0])
+		return 1
+	}
+	if s.Encrypted {
+		key, err := unlockVault(repo.Root())
+		if err == nil {
+			s, err = vault.DecryptSnippet(key, s)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snip get:", err)
+			return 1
+		}
+	}
+	_ = repo.RecordUse(s.ID)
+	fmt.Print(snippets.JoinParts(s))
+	return 0
+}
+
+// unlockVault derives the vault key from SNIPSTER_PASSPHRASE, or prompts on the terminal.
+func unlockVault(root string) (*vault.Key, error) {
+	v, err := vault.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	if !v.Initialized() {
+		return nil, fmt.Errorf("no vault in %s", root)
+	}
+	pass := os.Getenv("SNIPSTER_PASSPHRASE")
+	if pass == "" {
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("passphrase required (set SNIPSTER_PASSPHRASE): %w", err)
+		}
+		defer tty.Close()
+		fmt.Fprint(tty, "Vault passphrase: ")
+		b, err := term.ReadPassword(tty.Fd())
+		fmt.Fprintln(tty)
+		if err != nil {
+			return nil, err
+		}
+		pass = string(b)
+	}
+	return v.Unlock(pass)
+}
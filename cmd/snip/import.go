@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/importer"
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+const importUsage = "usage: snip import -markdown [-category c] [-dry-run] <file.md>...\n       snip import -from pet|navi|cheat [-category c] [-dry-run] <file or dir>..."
+
+// runImport adds snippets from other sources to the library: Markdown cheat
+// sheets (as written by snip export -markdown), or the libraries of pet, navi
+// and cheat. Snippets already in the library, by ID or by content in the same
+// category, are skipped.
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	markdown := flags.Bool("markdown", false, "read Markdown cheat sheets")
+	from := flags.String("from", "", "read a pet, navi or cheat library")
+	category := flags.String("category", "", "Markdown: file every snippet in this category; other formats: parent of the categories")
+	dryRun := flags.Bool("dry-run", false, "list the snippets without writing them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *markdown == (*from != "") || flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, importUsage)
+		return 2
+	}
+	if *from != "" && !slices.Contains(importer.Formats, *from) {
+		fmt.Fprintf(os.Stderr, "snip import: unknown format %q (want %s)\n", *from, strings.Join(importer.Formats, ", "))
+		return 2
+	}
+	parent := strings.Trim(*category, "/")
+	if err := snippets.ValidateCategory(parent); err != nil {
+		fmt.Fprintln(os.Stderr, "snip import:", err)
+		return 2
+	}
+	repo, _, all := openRepo()
+	var list []snippets.Snippet
+	if *markdown {
+		for _, path := range flags.Args() {
+			data, err := os.ReadFile(path)
+			if err == nil {
+				var got []snippets.Snippet
+				got, err = snippets.ParseCheatsheet(data)
+				list = append(list, got...)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "snip import: %s: %v\n", path, err)
+				return 1
+			}
+		}
+		if parent != "" {
+			for i := range list {
+				list[i].Category = parent
+			}
+		}
+	} else {
+		var err error
+		if list, err = readLibrary(*from, parent, flags.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, "snip import:", err)
+			return 1
+		}
+	}
+	return importSnippets(repo, all, list, *dryRun)
+}
+
+// readLibrary parses the given files, and the files of the given directories,
+// in format. Each file becomes a category named after it (git.cheat → git),
+// under parent when set; files in subdirectories keep their folder. Names that
+// would not make a valid category (hidden files…) are reported by importSnippets.
+func readLibrary(format, parent string, paths []string) ([]snippets.Snippet, error) {
+	var out []snippets.Snippet
+	read := func(path, rel string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		cat := filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		if parent != "" {
+			cat = parent + "/" + cat
+		}
+		got, err := importer.Parse(format, data, cat)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		out = append(out, got...)
+		return nil
+	}
+	for _, root := range paths {
+		fi, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			if err := read(root, filepath.Base(root)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !importer.Matches(format, d.Name()) {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, path)
+			return read(path, rel)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// importSnippets creates the snippets of list that are not in all yet, and
+// prints what was (or with dryRun, would be) imported per category.
+func importSnippets(repo *snippets.Repo, all, list []snippets.Snippet, dryRun bool) int {
+	added, skipped := 0, 0
+	perCat := map[string]int{}
+	var cats []string
+	for _, s := range list {
+		if dup, ok := findImported(all, s); ok {
+			fmt.Fprintf(os.Stderr, "skip   %s/%s: already in the library as %s\n", s.Category, s.Title, dup.Title)
+			skipped++
+			continue
+		}
+		if s.Title == "" || s.Category == "" {
+			fmt.Fprintf(os.Stderr, "skip   %q: title and category are required\n", s.Title)
+			skipped++
+			continue
+		}
+		if err := snippets.ValidateCategory(s.Category); err != nil {
+			fmt.Fprintf(os.Stderr, "skip   %q: %v\n", s.Title, err)
+			skipped++
+			continue
+		}
+		if !dryRun {
+			var err error
+			if s, err = repo.CreateAmong(all, s); err != nil {
+				fmt.Fprintln(os.Stderr, "snip import:", err)
+				return 1
+			}
+		}
+		all = append(all, s)
+		fmt.Printf("import %s/%s\n", s.Category, s.Title)
+		if perCat[s.Category] == 0 {
+			cats = append(cats, s.Category)
+		}
+		perCat[s.Category]++
+		added++
+	}
+	verb := "imported"
+	if dryRun {
+		verb = "would import"
+	}
+	for _, c := range cats {
+		fmt.Fprintf(os.Stderr, "%6d  %s\n", perCat[c], c)
+	}
+	fmt.Fprintf(os.Stderr, "%s %d snippets in %d categories, skipped %d\n", verb, added, len(cats), skipped)
+	return 0
+}
+
+// findImported returns the snippet of all that s duplicates: same ID, or same
+// category and content.
+func findImported(all []snippets.Snippet, s snippets.Snippet) (snippets.Snippet, bool) {
+	h := snippets.NormalizedHash(s)
+	for _, o := range all {
+		if (s.ID != "" && o.ID == s.ID) || (o.Category == s.Category && !o.Encrypted && snippets.NormalizedHash(o) == h) {
+			return o, true
+		}
+	}
+	return snippets.Snippet{}, false
+}
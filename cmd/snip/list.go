@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// runList prints the snippets as a table, optionally filtered by category,
+// search query or missing provenance.
+//
+//	snip list [-category c] [-missing-source] [query]
+func runList(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	category := fs.String("category", "", "only list this category and its children")
+	missingSource := fs.Bool("missing-source", false, "only list snippets without a source URL")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	_, _, all := openRepo()
+	var cats []string
+	if *category != "" {
+		cats = []string{*category}
+	}
+	var list []snippets.Snippet
+	for _, s := range snippets.Search(all, strings.Join(fs.Args(), " ")) {
+		if !underAny(s.Category, cats) || (*missingSource && s.SourceURL != "") {
+			continue
+		}
+		list = append(list, s)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Category != list[j].Category {
+			return list[i].Category < list[j].Category
+		}
+		return strings.ToLower(list[i].Title) < strings.ToLower(list[j].Title)
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tALIASES\tCATEGORY\tTITLE\tLANGUAGE\tSOURCE")
+	for _, s := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.ID, strings.Join(s.Aliases, ","), s.Category, s.Title, s.Language, s.SourceURL)
+	}
+	w.Flush()
+	if *missingSource {
+		fmt.Fprintf(os.Stderr, "%d of %d snippets have no source\n", len(list), len(all))
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/HrodWolfS/snipster/internal/lsp"
+)
+
+// runLSP speaks the Language Server Protocol on stdin/stdout, offering the
+// snippets as completions in any LSP-capable editor.
+//
+//	snip lsp [-category inbox]
+func runLSP(args []string) int {
+	fs := flag.NewFlagSet("lsp", flag.ContinueOnError)
+	category := fs.String("category", "inbox", "category of the snippets saved from the editor")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	repo, _, _ := openRepo()
+	if err := lsp.New(repo, *category).Serve(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "snip lsp:", err)
+		return 1
+	}
+	return 0
+}
@@ -60,17 +60,42 @@ func main() {
 		}
 	}
 
-	dataDir, err := ensureDataDir()
-	if err != nil {
-		log.Fatalf("failed to ensure data dir: %v", err)
-	}
-
-	repo := snippets.NewRepo(dataDir)
-	all, err := repo.LoadAll()
-	if err != nil {
-		log.Printf("warning: failed to load snippets: %v", err)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "pick":
+			os.Exit(runPick())
+		case "shell-init":
+			os.Exit(runShellInit(os.Args[2:]))
+		case "add":
+			os.Exit(runAdd(os.Args[2:]))
+		case "scan":
+			os.Exit(runScan())
+		case "list":
+			os.Exit(runList(os.Args[2:]))
+		case "get":
+			os.Exit(runGet(os.Args[2:]))
+		case "convert":
+			os.Exit(runConvert(os.Args[2:]))
+		case "materialize":
+			os.Exit(runMaterialize(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		case "dedupe":
+			os.Exit(runDedupe(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "lsp":
+			os.Exit(runLSP(os.Args[2:]))
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		case "remote":
+			os.Exit(runRemote(os.Args[2:]))
+		}
 	}
 
+	repo, dataDir, all := openRepo()
 	m := model.New(appContext{repo: repo, dataDir: dataDir}, all)
 
 	// Graceful shutdown on Ctrl+C
@@ -84,6 +109,23 @@ func main() {
 	}
 }
 
+// openRepo resolves the data dir and loads the library, shared by the TUI and subcommands.
+func openRepo() (*snippets.Repo, string, []snippets.Snippet) {
+	dataDir, err := ensureDataDir()
+	if err != nil {
+		log.Fatalf("failed to ensure data dir: %v", err)
+	}
+
+	repo := snippets.NewRepo(dataDir)
+	repo.SetFormat(snippets.FormatFromEnv())
+	repo.SetMigrateOnLoad(os.Getenv("SNIPSTER_MIGRATE_ON_LOAD") == "1")
+	all, err := repo.LoadAll()
+	if err != nil {
+		log.Printf("warning: failed to load snippets: %v", err)
+	}
+	return repo, dataDir, all
+}
+
 type appContext struct {
 	repo    *snippets.Repo
 	dataDir string
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+	"github.com/HrodWolfS/snipster/internal/vault"
+)
+
+// runMaterialize writes every file of a snippet bundle into a directory.
+//
+//	snip materialize [-force] <id> <dir>
+func runMaterialize(args []string) int {
+	fs := flag.NewFlagSet("materialize", flag.ContinueOnError)
+	force := fs.Bool("force", false, "overwrite existing files")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: snip materialize [-force] <id> <dir>")
+		return 2
+	}
+	repo, _, all := openRepo()
+	s, ok := snippets.Find(all, fs.Arg(0))
+	if !ok {
+		fmt.Fprintf(os.Stderr, "snip materialize: no snippet %q\n", fs.Arg(0))
+		return 1
+	}
+	if s.Encrypted {
+		key, err := unlockVault(repo.Root())
+		if err == nil {
+			s, err = vault.DecryptSnippet(key, s)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snip materialize:", err)
+			return 1
+		}
+	}
+	paths, err := snippets.Materialize(s, fs.Arg(1), *force)
+	for _, p := range paths {
+		fmt.Println(p)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip materialize:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/HrodWolfS/snipster/internal/model"
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// runPick runs the TUI on the controlling terminal and prints the chosen snippet
+// to stdout, so that shell widgets can capture it with $(snip pick).
+// Exit code 1 means nothing was picked.
+func runPick() int {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip pick: no terminal available:", err)
+		return 2
+	}
+	defer tty.Close()
+
+	// stdout is captured by the shell, so detect colors from the terminal instead.
+	lipgloss.SetColorProfile(lipgloss.NewRenderer(tty).ColorProfile())
+
+	repo, dataDir, all := openRepo()
+	m := model.New(appContext{repo: repo, dataDir: dataDir}, all)
+	m.State = model.StateHome
+	m.PickMode = true
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	p := tea.NewProgram(m, tea.WithContext(ctx), tea.WithInput(tty), tea.WithOutput(tty), tea.WithAltScreen())
+	final, err := p.Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip pick:", err)
+		return 1
+	}
+	fm, ok := final.(model.Model)
+	if !ok || fm.Picked == nil {
+		return 1
+	}
+
+	_ = repo.RecordUse(fm.Picked.ID)
+	content, err := fillPlaceholders(tty, fm.Picked.Content)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip pick:", err)
+		return 1
+	}
+	fmt.Print(strings.TrimRight(content, "\n"))
+	return 0
+}
+
+// fillPlaceholders prompts on the terminal for each {{placeholder}} of content.
+// An empty answer keeps the placeholder default.
+func fillPlaceholders(tty *os.File, content string) (string, error) {
+	phs := snippets.Placeholders(content)
+	if len(phs) == 0 {
+		return content, nil
+	}
+	r := bufio.NewReader(tty)
+	values := make(map[string]string, len(phs))
+	for _, ph := range phs {
+		if ph.Default != "" {
+			fmt.Fprintf(tty, "%s [%s]: ", ph.Name, ph.Default)
+		} else {
+			fmt.Fprintf(tty, "%s: ", ph.Name)
+		}
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		values[ph.Name] = strings.TrimRight(line, "\r\n")
+	}
+	return snippets.FillPlaceholders(content, values), nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/remote"
+	"github.com/HrodWolfS/snipster/internal/secrets"
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+const remoteUsage = `usage: snip remote list [-provider gist]
+       snip remote pull [-provider gist] [-category gists] [id...]
+       snip remote push [-provider gist] [-public] [-force] <id or slug>...`
+
+// runRemote syncs snippets with a remote host. pull files remote snippets in
+// a category, updating the ones pulled before; push creates or updates the
+// remote copy and records its ID in the snippet, after the same secret scan
+// as a save.
+func runRemote(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, remoteUsage)
+		return 2
+	}
+	verb := args[0]
+	flags := flag.NewFlagSet("remote "+verb, flag.ContinueOnError)
+	name := flags.String("provider", "gist", "remote host: "+strings.Join(remote.Providers, ", "))
+	category := flags.String("category", "gists", "pull: category of new snippets")
+	public := flags.Bool("public", false, "push: create public snippets")
+	force := flags.Bool("force", false, "push: push despite possible secrets (warn mode)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	p, err := remote.New(*name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip remote:", err)
+		return 2
+	}
+	ctx := context.Background()
+	switch verb {
+	case "list":
+		return remoteList(ctx, p)
+	case "pull":
+		return remotePull(ctx, p, strings.Trim(*category, "/"), flags.Args())
+	case "push":
+		if flags.NArg() == 0 {
+			break
+		}
+		return remotePush(ctx, p, *public, *force, flags.Args())
+	}
+	fmt.Fprintln(os.Stderr, remoteUsage)
+	return 2
+}
+
+func remoteList(ctx context.Context, p remote.Provider) int {
+	items, err := p.List(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip remote:", err)
+		return 1
+	}
+	_, _, all := openRepo()
+	for _, it := range items {
+		mark := " "
+		if _, ok := remote.Find(all, p.Name(), it.ID); ok {
+			mark = "*"
+		}
+		fmt.Printf("%s %s\t%s\n", mark, it.ID, it.Title)
+	}
+	return 0
+}
+
+// remotePull fetches ids, or every remote snippet when none is given.
+func remotePull(ctx context.Context, p remote.Provider, category string, ids []string) int {
+	if category == "" {
+		fmt.Fprintln(os.Stderr, "snip remote: -category is required")
+		return 2
+	}
+	if err := snippets.ValidateCategory(category); err != nil {
+		fmt.Fprintln(os.Stderr, "snip remote:", err)
+		return 2
+	}
+	if len(ids) == 0 {
+		items, err := p.List(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snip remote:", err)
+			return 1
+		}
+		for _, it := range items {
+			ids = append(ids, it.ID)
+		}
+	}
+	repo, _, all := openRepo()
+	status := 0
+	for _, id := range ids {
+		got, err := p.Pull(ctx, id)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snip remote:", err)
+			status = 1
+			continue
+		}
+		if cur, ok := remote.Find(all, p.Name(), id); ok {
+			// Keep the local metadata; the remote owns the title and code.
+			cur.Title, cur.Content, cur.Language, cur.Files = got.Title, got.Content, got.Language, got.Files
+			if _, err := repo.UpdateAmong(all, cur); err != nil {
+				fmt.Fprintln(os.Stderr, "snip remote:", err)
+				return 1
+			}
+			fmt.Printf("update %s/%s\n", cur.Category, cur.Title)
+			continue
+		}
+		got.Category = category
+		s, err := repo.CreateAmong(all, got)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snip remote:", err)
+			return 1
+		}
+		all = append(all, s)
+		fmt.Printf("pull   %s/%s\n", s.Category, s.Title)
+	}
+	return status
+}
+
+func remotePush(ctx context.Context, p remote.Provider, public, force bool, refs []string) int {
+	repo, _, all := openRepo()
+	mode := secrets.ModeFromEnv()
+	status := 0
+	for _, ref := range refs {
+		s, ok := snippets.Find(all, ref)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "snip remote: no snippet %q\n", ref)
+			status = 1
+			continue
+		}
+		// Pushing publishes: possible secrets stop it unless confirmed with
+		// -force, and block mode never lets them through.
+		if found := snippets.ScanSecrets(s); mode != secrets.ModeOff && len(found) > 0 {
+			printFindings(os.Stderr, s.Path, found)
+			if mode == secrets.ModeBlock || !force {
+				fmt.Fprintf(os.Stderr, "snip remote: not pushing %s: possible secrets (replace them, or use -force in warn mode)\n", s.Title)
+				status = 1
+				continue
+			}
+		}
+		id, err := p.Push(ctx, s, public)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snip remote:", err)
+			status = 1
+			continue
+		}
+		verb := "update"
+		if s.Remotes[p.Name()] != id {
+			verb = "create"
+			if s.Remotes == nil {
+				s.Remotes = map[string]string{}
+			}
+			s.Remotes[p.Name()] = id
+			if _, err := repo.Update(s); err != nil {
+				fmt.Fprintln(os.Stderr, "snip remote:", err)
+				return 1
+			}
+		}
+		fmt.Printf("%s %s %s\n", verb, id, s.Title)
+	}
+	return status
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// runScan audits the whole library for secrets: contents, bundle files and
+// descriptions. Exit code 1 when something is found.
+func runScan() int {
+	_, _, all := openRepo()
+	n := 0
+	for _, s := range all {
+		found := snippets.ScanSecrets(s)
+		printFindings(os.Stdout, s.Path, found)
+		n += len(found)
+	}
+	if n > 0 {
+		fmt.Fprintf(os.Stderr, "%d possible secret(s) found in %d snippets\n", n, len(all))
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "no secrets found in %d snippets\n", len(all))
+	return 0
+}
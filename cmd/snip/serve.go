@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/HrodWolfS/snipster/internal/server"
+)
+
+// runServe serves the library over a local HTTP/JSON API.
+//
+//	snip serve [-addr host:port | -socket path] [-token t] [-poll d]
+//
+// Clients authenticate with "Authorization: Bearer <token>". The token comes
+// from -token or SNIPSTER_TOKEN; without one, a random token is printed.
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:7878", "TCP address to listen on")
+	socket := fs.String("socket", "", "listen on this Unix socket instead of TCP")
+	token := fs.String("token", os.Getenv("SNIPSTER_TOKEN"), "bearer token required from clients")
+	poll := fs.Duration("poll", 2*time.Second, "how often to look for changes made outside the server")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *token == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			fmt.Fprintln(os.Stderr, "snip serve:", err)
+			return 1
+		}
+		*token = hex.EncodeToString(b)
+		fmt.Fprintf(os.Stderr, "token: %s\n", *token)
+	}
+
+	var ln net.Listener
+	var err error
+	if *socket != "" {
+		// A socket left by a previous run would make Listen fail.
+		if fi, err := os.Lstat(*socket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(*socket)
+		}
+		ln, err = net.Listen("unix", *socket)
+		if err == nil {
+			err = os.Chmod(*socket, 0o600)
+		}
+	} else {
+		ln, err = net.Listen("tcp", *addr)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snip serve:", err)
+		return 1
+	}
+
+	repo, _, _ := openRepo()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := server.New(repo, *token)
+	go srv.Watch(ctx, *poll)
+	hs := &http.Server{Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = hs.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(os.Stderr, "serving %s on %s\n", repo.Root(), ln.Addr())
+	if err := hs.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, "snip serve:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Shell widgets bound to Ctrl+S. They call `snip pick` and insert its output at the cursor.
+// Ctrl+S is also the XOFF flow-control key, so bash/zsh disable it with `stty -ixon`.
+const zshInit = `# snipster: Ctrl+S inserts a snippet into the command line
+stty -ixon 2>/dev/null
+_snip_pick_widget() {
+  local out
+  out="$(snip pick)" || { zle reset-prompt; return }
+  LBUFFER="${LBUFFER}${out}"
+  zle reset-prompt
+}
+zle -N _snip_pick_widget
+bindkey '^S' _snip_pick_widget
+`
+
+const bashInit = `# snipster: Ctrl+S inserts a snippet into the command line
+stty -ixon 2>/dev/null
+_snip_pick_widget() {
+  local out
+  out="$(snip pick)" || return
+  READLINE_LINE="${READLINE_LINE:0:$READLINE_POINT}${out}${READLINE_LINE:$READLINE_POINT}"
+  READLINE_POINT=$(( READLINE_POINT + ${#out} ))
+}
+bind -x '"\C-s": _snip_pick_widget'
+`
+
+const fishInit = `# snipster: Ctrl+S inserts a snippet into the command line
+function _snip_pick_widget
+  set -l out (snip pick | string collect)
+  and commandline -i -- $out
+  commandline -f repaint
+end
+bind \cs _snip_pick_widget
+`
+
+// runShellInit prints the widget for the given shell, meant to be eval'd from the shell rc file.
+func runShellInit(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: snip shell-init zsh|bash|fish")
+		return 2
+	}
+	switch args[0] {
+	case "zsh":
+		fmt.Print(zshInit)
+	case "bash":
+		fmt.Print(bashInit)
+	case "fish":
+		fmt.Print(fishInit)
+	default:
+		fmt.Fprintf(os.Stderr, "snip shell-init: unsupported shell %q (zsh, bash, fish)\n", args[0])
+		return 2
+	}
+	return 0
+}
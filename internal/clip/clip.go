@@ -0,0 +1,252 @@
+// Package clip copies text to the best clipboard available: the system
+// clipboard, the terminal's (OSC 52, which also works over SSH), a tmux
+// buffer, or as a last resort a file.
+package clip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// Method is a way of copying text.
+type Method string
+
+const (
+	MethodAuto   Method = "auto"
+	MethodSystem Method = "system" // xclip/xsel/wl-copy, pbcopy, Windows clipboard
+	MethodOSC52  Method = "osc52"  // escape sequence handled by the terminal emulator
+	MethodTmux   Method = "tmux"
+	MethodFile   Method = "file"
+)
+
+// FileName is the file written by MethodFile, in the per-user directory
+// returned by fileDir.
+const FileName = "snipster-clipboard.txt"
+
+// fileDir returns the private directory holding the MethodFile clipboard,
+// <user cache dir>/snipster, creating it readable by the user only. A shared
+// temp directory would let another user plant the file or a symlink.
+func fileDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "snipster")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, os.Chmod(dir, 0o700)
+}
+
+// MethodFromEnv reads SNIPSTER_CLIPBOARD (auto|system|osc52|tmux|file), defaulting to auto.
+func MethodFromEnv() Method {
+	switch m := Method(strings.ToLower(strings.TrimSpace(os.Getenv("SNIPSTER_CLIPBOARD")))); m {
+	case MethodSystem, MethodOSC52, MethodTmux, MethodFile:
+		return m
+	default:
+		return MethodAuto
+	}
+}
+
+// Result tells where the text actually went.
+type Result struct {
+	Method Method
+	// Tmux is set when a tmux buffer received the text as well as the terminal.
+	Tmux bool
+	// Path of the file written by MethodFile.
+	Path string
+	// Fallback is set when MethodAuto found no clipboard at all.
+	Fallback bool
+}
+
+// String describes the outcome for the status bar.
+func (r Result) String() string {
+	switch r.Method {
+	case MethodOSC52:
+		if r.Tmux {
+			return "sent to terminal clipboard (OSC 52) and tmux buffer"
+		}
+		return "sent to terminal clipboard (OSC 52)"
+	case MethodTmux:
+		return "copied to tmux buffer"
+	case MethodFile:
+		if r.Fallback {
+			return "no clipboard available: saved to " + r.Path
+		}
+		return "saved to " + r.Path
+	default:
+		return "copied to clipboard"
+	}
+}
+
+// Copy copies text with method m. MethodAuto tries the system clipboard, then
+// OSC 52 when a terminal is attached (plus a tmux buffer inside tmux), then
+// tmux alone, then a file.
+func Copy(text string, m Method) (Result, error) {
+	switch m {
+	case MethodSystem:
+		return Result{Method: MethodSystem}, copySystem(text)
+	case MethodOSC52:
+		return Result{Method: MethodOSC52}, copyOSC52(text)
+	case MethodTmux:
+		return Result{Method: MethodTmux}, copyTmux(text)
+	case MethodFile:
+		path, err := copyFile(text)
+		return Result{Method: MethodFile, Path: path}, err
+	}
+
+	if copySystem(text) == nil {
+		return Result{Method: MethodSystem}, nil
+	}
+	inTmux := os.Getenv("TMUX") != ""
+	if os.Getenv("TERM") != "dumb" && copyOSC52(text) == nil {
+		return Result{Method: MethodOSC52, Tmux: inTmux && copyTmux(text) == nil}, nil
+	}
+	if inTmux && copyTmux(text) == nil {
+		return Result{Method: MethodTmux}, nil
+	}
+	path, err := copyFile(text)
+	return Result{Method: MethodFile, Path: path, Fallback: true}, err
+}
+
+func copySystem(text string) error {
+	if clipboard.Unsupported {
+		return errors.New("no system clipboard (install xclip, xsel or wl-clipboard)")
+	}
+	return clipboard.WriteAll(text)
+}
+
+// copyOSC52 writes the sequence to the controlling terminal, wrapped for tmux or
+// screen so that the multiplexer passes it through.
+func copyOSC52(text string) error {
+	tty, err := openTTY()
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	_, err = seq.WriteTo(tty)
+	return err
+}
+
+func openTTY() (io.WriteCloser, error) {
+	f, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no terminal: %w", err)
+	}
+	return f, nil
+}
+
+func copyTmux(text string) error {
+	if os.Getenv("TMUX") == "" {
+		return errors.New("not inside tmux")
+	}
+	cmd := exec.Command("tmux", "load-buffer", "-")
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("tmux load-buffer: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// copyFile writes text to a fresh file created with O_EXCL, then renames it
+// over the clipboard file, so an existing file or symlink is replaced rather
+// than written through.
+func copyFile(text string) (string, error) {
+	dir, err := fileDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, FileName)
+	f, err := os.CreateTemp(dir, ".clip-*")
+	if err != nil {
+		return path, err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return path, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return path, err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return path, err
+	}
+	return path, nil
+}
+
+// Paste reads the clipboard with method m. MethodAuto tries the system
+// clipboard, then the tmux buffer; the file written by MethodFile is only read
+// when asked for, since stale content there would be imported silently.
+// OSC 52 cannot be read back: terminals rarely answer clipboard queries.
+func Paste(m Method) (string, error) {
+	switch m {
+	case MethodSystem:
+		return pasteSystem()
+	case MethodTmux:
+		return pasteTmux()
+	case MethodFile:
+		return pasteFile()
+	case MethodOSC52:
+		return "", errors.New("reading the clipboard is not supported with osc52")
+	}
+	for _, paste := range []func() (string, error){pasteSystem, pasteTmux} {
+		if text, err := paste(); err == nil && text != "" {
+			return text, nil
+		}
+	}
+	return "", errors.New("clipboard is empty or unavailable")
+}
+
+func pasteSystem() (string, error) {
+	if clipboard.Unsupported {
+		return "", errors.New("no system clipboard (install xclip, xsel or wl-clipboard)")
+	}
+	return clipboard.ReadAll()
+}
+
+func pasteTmux() (string, error) {
+	if os.Getenv("TMUX") == "" {
+		return "", errors.New("not inside tmux")
+	}
+	out, err := exec.Command("tmux", "show-buffer").Output()
+	if err != nil {
+		return "", fmt.Errorf("tmux show-buffer: %w", err)
+	}
+	return string(out), nil
+}
+
+// pasteFile reads the clipboard file, refusing anything but a regular file.
+func pasteFile() (string, error) {
+	dir, err := fileDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, FileName)
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+	if !fi.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+	b, err := os.ReadFile(path)
+	return string(b), err
+}
@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// parseNavi reads a navi .cheat file:
+//
+//	% git, code
+//
+//	# Change branch
+//	git checkout <branch>
+//
+//	$ branch: git branch | awk '{print $NF}'
+//
+// "%" lines give the tags of the snippets that follow, "#" lines their
+// description and the next lines up to a blank or marker line their command.
+// Variable sources ($), extends (@) and comments (;) are dropped.
+func parseNavi(text string, category string) []snippets.Snippet {
+	var out []snippets.Snippet
+	var tags []string
+	var desc string
+	var cmd []string
+	flush := func() {
+		if len(cmd) > 0 {
+			out = append(out, newSnippet(desc, category, "bash", strings.Join(cmd, "\n"), tags))
+		}
+		desc, cmd = "", nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		t := strings.TrimSpace(line)
+		switch {
+		case t == "":
+			flush()
+		case strings.HasPrefix(t, "%"):
+			flush()
+			tags = splitTags(strings.TrimPrefix(t, "%"))
+		case strings.HasPrefix(t, "#"):
+			flush()
+			desc = strings.TrimSpace(strings.TrimPrefix(t, "#"))
+		case strings.HasPrefix(t, "$"), strings.HasPrefix(t, "@"), strings.HasPrefix(t, ";"):
+			flush()
+		default:
+			cmd = append(cmd, line)
+		}
+	}
+	flush()
+	return out
+}
+
+// parseCheat reads a cheat/cheatsheets file: optional front matter (syntax,
+// tags) then entries made of "#" comment lines describing the command lines
+// that follow, separated by blank lines.
+func parseCheat(text string, category string) []snippets.Snippet {
+	lang := "bash"
+	var tags []string
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		if fm, body, ok := strings.Cut(rest, "\n---\n"); ok {
+			text = body
+			for _, line := range strings.Split(fm, "\n") {
+				k, v, _ := strings.Cut(line, ":")
+				v = strings.TrimSpace(v)
+				switch strings.TrimSpace(k) {
+				case "syntax":
+					lang = v
+				case "tags":
+					tags = splitTags(strings.Trim(v, "[]"))
+				}
+			}
+		}
+	}
+	var out []snippets.Snippet
+	var desc []string
+	var cmd []string
+	flush := func() {
+		if len(cmd) > 0 {
+			out = append(out, newSnippet(strings.Join(desc, " "), category, lang, strings.Join(cmd, "\n"), tags))
+		}
+		desc, cmd = nil, nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		t := strings.TrimSpace(line)
+		switch {
+		case t == "":
+			flush()
+		case strings.HasPrefix(t, "#") && len(cmd) == 0:
+			desc = append(desc, strings.TrimSpace(strings.TrimPrefix(t, "#")))
+		case strings.HasPrefix(t, "#"):
+			// A new comment after commands starts the next entry.
+			flush()
+			desc = append(desc, strings.TrimSpace(strings.TrimPrefix(t, "#")))
+		default:
+			cmd = append(cmd, line)
+		}
+	}
+	flush()
+	return out
+}
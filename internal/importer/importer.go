@@ -0,0 +1,105 @@
+// Package importer reads snippet libraries of other tools: pet (TOML),
+// navi (.cheat files) and cheat/cheatsheets (plain text).
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// Format names accepted by Parse.
+const (
+	Pet   = "pet"
+	Navi  = "navi"
+	Cheat = "cheat"
+)
+
+// Formats lists the supported formats.
+var Formats = []string{Pet, Navi, Cheat}
+
+// Longest title made from a command when a snippet has no description.
+const maxTitle = 60
+
+// Parse reads the snippets of one file in the given format, filing them in
+// category.
+func Parse(format string, data []byte, category string) ([]snippets.Snippet, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	switch format {
+	case Pet:
+		return parsePet(text, category)
+	case Navi:
+		return parseNavi(text, category), nil
+	case Cheat:
+		return parseCheat(text, category), nil
+	}
+	return nil, fmt.Errorf("unknown format %q (want %s, %s or %s)", format, Pet, Navi, Cheat)
+}
+
+// Matches reports whether a file name belongs to format, when importing a
+// whole directory.
+func Matches(format, name string) bool {
+	switch format {
+	case Pet:
+		return strings.HasSuffix(name, ".toml")
+	case Navi:
+		return strings.HasSuffix(name, ".cheat")
+	}
+	// cheatsheets are named after the command, without extension.
+	return !strings.HasPrefix(name, ".") && !strings.Contains(name, ".")
+}
+
+// <name> and <name=default> parameters, as written by pet, navi and cheat.
+var paramRe = regexp.MustCompile(`<([A-Za-z_][A-Za-z0-9_.-]*)(?:=([^<>]*))?>`)
+
+// placeholders turns <name> and <name=default> into {{name}} and
+// {{name:default}}. A pet choice list, <name=|_a_||_b_|>, keeps its first value.
+func placeholders(cmd string) string {
+	return paramRe.ReplaceAllStringFunc(cmd, func(m string) string {
+		sub := paramRe.FindStringSubmatch(m)
+		name, def := sub[1], sub[2]
+		if rest, ok := strings.CutPrefix(def, "|_"); ok {
+			def, _, _ = strings.Cut(rest, "_|")
+		}
+		if def = strings.TrimSpace(def); def != "" {
+			return "{{" + name + ":" + def + "}}"
+		}
+		return "{{" + name + "}}"
+	})
+}
+
+// titleFrom returns the first line of a command, shortened, for snippets
+// without a description.
+func titleFrom(cmd string) string {
+	first, _, _ := strings.Cut(strings.TrimSpace(cmd), "\n")
+	if r := []rune(first); len(r) > maxTitle {
+		return string(r[:maxTitle]) + "…"
+	}
+	return first
+}
+
+func newSnippet(title, category, lang, cmd string, tags []string) snippets.Snippet {
+	title = strings.TrimSuffix(strings.TrimSpace(title), ":")
+	if title == "" {
+		title = titleFrom(cmd)
+	}
+	return snippets.Snippet{
+		Title:    title,
+		Category: category,
+		Language: lang,
+		Tags:     tags,
+		Content:  placeholders(strings.TrimSpace(cmd)) + "\n",
+	}
+}
+
+func splitTags(s string) []string {
+	var out []string
+	for _, t := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if t = strings.Trim(t, `"'`); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
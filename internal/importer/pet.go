@@ -0,0 +1,232 @@
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// parsePet reads a pet snippet file:
+//
+//	[[snippets]]
+//	  description = "ping"
+//	  command = "ping <host=8.8.8.8>"
+//	  tag = ["network"]
+//
+// Only the subset of TOML pet writes is understood: [[snippets]] tables of
+// strings (basic, literal and multi-line) and string arrays.
+func parsePet(text string, category string) ([]snippets.Snippet, error) {
+	var out []snippets.Snippet
+	var cur map[string]any
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		cmd, _ := cur["command"].(string)
+		if strings.TrimSpace(cmd) != "" {
+			desc, _ := cur["description"].(string)
+			tags, _ := cur["tag"].([]string)
+			out = append(out, newSnippet(desc, category, "bash", cmd, tags))
+		}
+		cur = nil
+	}
+	p := &tomlParser{text: text}
+	for p.skipSpace(); !p.eof(); p.skipSpace() {
+		if p.consume("[[snippets]]") {
+			flush()
+			cur = map[string]any{}
+			continue
+		}
+		if p.peek() == '[' {
+			return nil, p.errorf("unexpected table")
+		}
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		p.skipBlank()
+		if !p.consume("=") {
+			return nil, p.errorf("missing '=' after %s", key)
+		}
+		p.skipBlank()
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if cur != nil {
+			cur[key] = v
+		}
+	}
+	flush()
+	return out, nil
+}
+
+type tomlParser struct {
+	text string
+	pos  int
+}
+
+func (p *tomlParser) eof() bool { return p.pos >= len(p.text) }
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.text[p.pos]
+}
+
+func (p *tomlParser) consume(s string) bool {
+	if strings.HasPrefix(p.text[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	line := strings.Count(p.text[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipBlank skips spaces and tabs.
+func (p *tomlParser) skipBlank() {
+	for p.peek() == ' ' || p.peek() == '\t' {
+		p.pos++
+	}
+}
+
+// skipSpace skips whitespace, new lines and comments.
+func (p *tomlParser) skipSpace() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\n', '\r', ',':
+			p.pos++
+		case '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) key() (string, error) {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == ' ' || c == '\t' || c == '=' || c == '\n' {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("missing key")
+	}
+	return p.text[start:p.pos], nil
+}
+
+func (p *tomlParser) value() (any, error) {
+	switch {
+	case p.consume(`"""`):
+		return p.until(`"""`, true)
+	case p.consume(`'''`):
+		return p.until(`'''`, false)
+	case p.consume(`"`):
+		return p.until(`"`, true)
+	case p.consume(`'`):
+		return p.until(`'`, false)
+	case p.consume("["):
+		var list []string
+		for p.skipSpace(); !p.consume("]"); p.skipSpace() {
+			if p.eof() {
+				return nil, p.errorf("unterminated array")
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			s, ok := v.(string)
+			if !ok {
+				return nil, p.errorf("only string arrays are supported")
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	}
+	// Numbers, booleans and dates are not used by pet snippets: keep them raw.
+	start := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+	return strings.TrimSpace(p.text[start:p.pos]), nil
+}
+
+// until reads a string up to its closing quote; basic strings have escapes.
+func (p *tomlParser) until(quote string, escapes bool) (string, error) {
+	start := p.pos
+	for !p.eof() {
+		if escapes && p.peek() == '\\' {
+			p.pos += 2
+			continue
+		}
+		if p.consume(quote) {
+			raw := p.text[start : p.pos-len(quote)]
+			// A new line right after the opening quotes is not part of the string.
+			if len(quote) == 3 {
+				raw = strings.TrimPrefix(raw, "\n")
+			}
+			if !escapes {
+				return raw, nil
+			}
+			return unescape(raw)
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func unescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '"', '\\':
+			b.WriteByte(s[i])
+		case 'u', 'U':
+			n := 4
+			if s[i] == 'U' {
+				n = 8
+			}
+			if i+1+n > len(s) {
+				return "", fmt.Errorf("invalid escape in %q", s)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape in %q", s)
+			}
+			b.WriteRune(rune(r))
+			i += n
+		case '\n':
+			// Line ending backslash: skip the new line and leading whitespace.
+			for i+1 < len(s) && strings.ContainsRune(" \t\n", rune(s[i+1])) {
+				i++
+			}
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
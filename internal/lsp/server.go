@@ -0,0 +1,429 @@
+// Package lsp serves snippets to editors over the Language Server Protocol:
+// completions filtered by the document language, and a code action saving the
+// selection as a new snippet.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// CommandSaveSelection is the command run by the "save selection" code action.
+const CommandSaveSelection = "snipster.saveSelection"
+
+// Longest title derived from the first line of a saved selection.
+const maxTitle = 60
+
+var errParse = errors.New("parse error")
+
+// Server answers one editor session.
+type Server struct {
+	repo *snippets.Repo
+	// Category of the snippets saved from the editor.
+	category string
+	conn     *conn
+	docs     map[string]*document
+	shutdown bool
+}
+
+type document struct {
+	lang string
+	text string
+}
+
+// Protocol types, limited to the fields used here.
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type textRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type textDocumentID struct {
+	URI string `json:"uri"`
+}
+
+type completionItem struct {
+	Label            string  `json:"label"`
+	Kind             int     `json:"kind"`
+	Detail           string  `json:"detail,omitempty"`
+	Documentation    *markup `json:"documentation,omitempty"`
+	FilterText       string  `json:"filterText,omitempty"`
+	InsertTextFormat int     `json:"insertTextFormat"`
+	TextEdit         any     `json:"textEdit,omitempty"`
+}
+
+type markup struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type textEdit struct {
+	Range   textRange `json:"range"`
+	NewText string    `json:"newText"`
+}
+
+type command struct {
+	Title     string `json:"title"`
+	Command   string `json:"command"`
+	Arguments []any  `json:"arguments,omitempty"`
+}
+
+type codeAction struct {
+	Title   string   `json:"title"`
+	Kind    string   `json:"kind"`
+	Command *command `json:"command"`
+}
+
+// selection is the argument of CommandSaveSelection.
+type selection struct {
+	URI   string    `json:"uri"`
+	Range textRange `json:"range"`
+}
+
+// LSP constants.
+const (
+	syncFull      = 1
+	kindSnippet   = 15
+	formatSnippet = 2
+	messageInfo   = 3
+	messageError  = 1
+)
+
+// New returns a server for repo; snippets saved from the editor go to category.
+func New(repo *snippets.Repo, category string) *Server {
+	return &Server{repo: repo, category: category, docs: map[string]*document{}}
+}
+
+// Serve handles requests from r until the client sends exit or closes r.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+	for {
+		m, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if errors.Is(err, errParse) {
+			_ = s.conn.reply(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if m.Method == "exit" {
+			return nil
+		}
+		result, rerr := s.handle(m)
+		if m.ID == nil {
+			continue // notification
+		}
+		if err := s.conn.reply(m.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(m *message) (any, *rpcError) {
+	if s.shutdown && m.Method != "exit" {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	}
+	switch m.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":       syncFull,
+				"completionProvider":     map[string]any{"resolveProvider": false},
+				"codeActionProvider":     true,
+				"executeCommandProvider": map[string]any{"commands": []string{CommandSaveSelection}},
+			},
+			"serverInfo": map[string]string{"name": "snipster"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p struct {
+			TextDocument struct {
+				URI        string `json:"uri"`
+				LanguageID string `json:"languageId"`
+				Text       string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.docs[p.TextDocument.URI] = &document{lang: p.TextDocument.LanguageID, text: p.TextDocument.Text}
+		return nil, nil
+	case "textDocument/didChange":
+		var p struct {
+			TextDocument   textDocumentID `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		// Full sync: the last change holds the whole document.
+		if d := s.docs[p.TextDocument.URI]; d != nil && len(p.ContentChanges) > 0 {
+			d.text = p.ContentChanges[len(p.ContentChanges)-1].Text
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var p struct {
+			TextDocument textDocumentID `json:"textDocument"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return nil, nil
+	case "textDocument/completion":
+		var p struct {
+			TextDocument textDocumentID `json:"textDocument"`
+			Position     position       `json:"position"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.complete(p.TextDocument.URI, p.Position)
+	case "textDocument/codeAction":
+		var p struct {
+			TextDocument textDocumentID `json:"textDocument"`
+			Range        textRange      `json:"range"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		actions := []codeAction{}
+		if p.Range.Start != p.Range.End {
+			actions = append(actions, codeAction{
+				Title: "Save selection as snippet",
+				Kind:  "refactor",
+				Command: &command{
+					Title:     "Save selection as snippet",
+					Command:   CommandSaveSelection,
+					Arguments: []any{selection{URI: p.TextDocument.URI, Range: p.Range}},
+				},
+			})
+		}
+		return actions, nil
+	case "workspace/executeCommand":
+		var p struct {
+			Command   string      `json:"command"`
+			Arguments []selection `json:"arguments"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		if p.Command != CommandSaveSelection || len(p.Arguments) != 1 {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "unknown command " + p.Command}
+		}
+		s.saveSelection(p.Arguments[0])
+		return nil, nil
+	}
+	if strings.HasPrefix(m.Method, "$/") || m.ID == nil {
+		// Optional notifications (initialized, $/cancelRequest…) are ignored.
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not supported: " + m.Method}
+}
+
+// complete lists the snippets of the document's language (and those without a
+// language) matching the word before the cursor, which the completion replaces.
+func (s *Server) complete(uri string, pos position) (any, *rpcError) {
+	d := s.docs[uri]
+	if d == nil {
+		return []completionItem{}, nil
+	}
+	all, err := s.repo.LoadAll()
+	if err != nil {
+		return nil, &rpcError{Code: codeInternalError, Message: err.Error()}
+	}
+	line := lineAt(d.text, pos.Line)
+	col := byteOffset(line, pos.Character)
+	start := col
+	for start > 0 {
+		c, size := utf8.DecodeLastRuneInString(line[:start])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '-' {
+			break
+		}
+		start -= size
+	}
+	prefix := strings.ToLower(line[start:col])
+	replace := textRange{
+		Start: position{Line: pos.Line, Character: utf16Len(line[:start])},
+		End:   pos,
+	}
+
+	lang := canonLang(d.lang)
+	items := []completionItem{}
+	for _, sn := range all {
+		if sn.Encrypted || sn.Content == "" {
+			continue
+		}
+		if sn.Language != "" && canonLang(sn.Language) != lang {
+			continue
+		}
+		slug := sn.Slug()
+		alias := aliasWithPrefix(sn, prefix)
+		if prefix != "" && alias == "" && !strings.HasPrefix(slug, prefix) && !strings.Contains(strings.ToLower(sn.Title), prefix) {
+			continue
+		}
+		// Typing an alias selects its snippet.
+		filter := slug
+		if alias != "" {
+			filter = alias
+		}
+		doc := "```" + sn.Language + "\n" + strings.TrimRight(sn.Content, "\n") + "\n```"
+		if d := strings.TrimSpace(sn.Description); d != "" {
+			doc = d + "\n\n" + doc
+		}
+		items = append(items, completionItem{
+			Label:            sn.Title,
+			Kind:             kindSnippet,
+			Detail:           sn.Category,
+			Documentation:    &markup{Kind: "markdown", Value: doc},
+			FilterText:       filter,
+			InsertTextFormat: formatSnippet,
+			TextEdit:         textEdit{Range: replace, NewText: snippets.TabStops(sn.Content)},
+		})
+	}
+	return items, nil
+}
+
+// saveSelection creates a snippet from the selected text, titled after its
+// first line, and tells the user where it went.
+func (s *Server) saveSelection(sel selection) {
+	d := s.docs[sel.URI]
+	if d == nil {
+		s.showMessage(messageError, "snipster: document is not open")
+		return
+	}
+	text := textIn(d.text, sel.Range)
+	if strings.TrimSpace(text) == "" {
+		s.showMessage(messageError, "snipster: the selection is empty")
+		return
+	}
+	title := ""
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			title = l
+			break
+		}
+	}
+	if r := []rune(title); len(r) > maxTitle {
+		title = string(r[:maxTitle]) + "…"
+	}
+	sn, err := s.repo.Create(snippets.Snippet{
+		Title:    title,
+		Category: s.category,
+		Language: canonLang(d.lang),
+		Content:  text,
+	})
+	if err != nil {
+		s.showMessage(messageError, "snipster: "+err.Error())
+		return
+	}
+	s.showMessage(messageInfo, fmt.Sprintf("snipster: saved %q in %s", sn.Title, sn.Category))
+}
+
+func (s *Server) showMessage(typ int, msg string) {
+	_ = s.conn.notify("window/showMessage", map[string]any{"type": typ, "message": msg})
+}
+
+func invalidParams(err error) *rpcError {
+	return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+// Editor language IDs and snippet languages naming the same language.
+var langAliases = map[string]string{
+	"js": "javascript", "javascriptreact": "javascript", "jsx": "javascript",
+	"ts": "typescript", "typescriptreact": "typescript", "tsx": "typescript",
+	"sh": "shell", "bash": "shell", "zsh": "shell", "shellscript": "shell",
+	"golang": "go", "yml": "yaml", "py": "python", "rb": "ruby",
+	"docker": "dockerfile", "md": "markdown",
+}
+
+func canonLang(l string) string {
+	l = strings.ToLower(strings.TrimSpace(l))
+	if a, ok := langAliases[l]; ok {
+		return a
+	}
+	return l
+}
+
+// lineAt returns line n of text, without its line break.
+func lineAt(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	if n < 0 || n >= len(lines) {
+		return ""
+	}
+	return strings.TrimSuffix(lines[n], "\r")
+}
+
+// byteOffset converts an LSP character offset (UTF-16 code units) in line to
+// a byte offset, clamped to the line.
+func byteOffset(line string, char int) int {
+	units := 0
+	for i, r := range line {
+		if units >= char {
+			return i
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(line)
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
+
+// textIn returns the text of the document between the range ends.
+func textIn(text string, rg textRange) string {
+	lines := strings.SplitAfter(text, "\n")
+	offset := func(p position) int {
+		off := 0
+		for i := 0; i < p.Line && i < len(lines); i++ {
+			off += len(lines[i])
+		}
+		if p.Line < len(lines) {
+			off += byteOffset(lines[p.Line], p.Character)
+		}
+		return off
+	}
+	start, end := offset(rg.Start), offset(rg.End)
+	if start > end {
+		start, end = end, start
+	}
+	return text[start:end]
+}
+
+// aliasWithPrefix returns the first alias of s starting with prefix, if any.
+func aliasWithPrefix(s snippets.Snippet, prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	for _, a := range s.Aliases {
+		if strings.HasPrefix(a, prefix) {
+			return a
+		}
+	}
+	return ""
+}
@@ -0,0 +1,290 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+	"github.com/HrodWolfS/snipster/internal/ui"
+)
+
+// Batch operations on the selected snippets.
+type batchOp int
+
+const (
+	batchDelete batchOp = iota
+	batchMove
+	batchTagAdd
+	batchTagRemove
+	batchExport
+)
+
+// Titles listed in the batch confirmation before "… and N more".
+const batchSummaryMax = 8
+
+func (op batchOp) String() string {
+	switch op {
+	case batchMove:
+		return "Move"
+	case batchTagAdd:
+		return "Add tag to"
+	case batchTagRemove:
+		return "Remove tag from"
+	case batchExport:
+		return "Export"
+	default:
+		return "Delete"
+	}
+}
+
+// toggleSelect flips the selection of the snippet under the cursor and makes it
+// the anchor of the next range selection.
+func (m *Model) toggleSelect() {
+	idx := m.List.Index()
+	s, ok := m.currentSnippet()
+	if !ok {
+		return
+	}
+	if m.selected == nil {
+		m.selected = map[string]bool{}
+	}
+	if m.selected[s.Path] {
+		delete(m.selected, s.Path)
+	} else {
+		m.selected[s.Path] = true
+	}
+	m.selAnchor = idx
+	m.syncSelection()
+}
+
+// selectRange selects every snippet between the anchor and the cursor.
+func (m *Model) selectRange() {
+	from, to := m.selAnchor, m.List.Index()
+	if from < 0 || from >= len(m.VisibleItems) {
+		from = to
+	}
+	if from > to {
+		from, to = to, from
+	}
+	if m.selected == nil {
+		m.selected = map[string]bool{}
+	}
+	for _, it := range m.VisibleItems[from : to+1] {
+		if it.Kind == SidebarItemSnippet && it.Snippet != nil {
+			m.selected[it.Snippet.Path] = true
+		}
+	}
+	m.syncSelection()
+}
+
+func (m *Model) clearSelection() {
+	m.selected = nil
+	m.selAnchor = -1
+	m.syncSelection()
+}
+
+// syncSelection refreshes the check marks of the visible list items.
+func (m *Model) syncSelection() {
+	for i := range m.VisibleItems {
+		it := &m.VisibleItems[i]
+		it.Selected = it.Snippet != nil && m.selected[it.Snippet.Path]
+		m.List.SetItem(i, *it)
+	}
+	if n := len(m.selected); n > 0 {
+		m.Status = fmt.Sprintf("%d selected", n)
+	}
+}
+
+// selectedSnippets returns the selected snippets in sidebar order.
+func (m *Model) selectedSnippets() []snippets.Snippet {
+	var out []snippets.Snippet
+	for _, s := range sortSnippets(m.Snippets) {
+		if m.selected[s.Path] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// startBatch asks for the operation argument when needed, then for confirmation.
+func (m *Model) startBatch(op batchOp) {
+	m.batchOp = op
+	m.batchArg = ""
+	var prompt, value string
+	switch op {
+	case batchMove:
+		prompt, value = "Category: ", m.CurrentPath
+	case batchTagAdd, batchTagRemove:
+		prompt = "Tag: "
+	case batchExport:
+		prompt, value = "Directory: ", "snipster-export"
+	default:
+		m.State = StateConfirmBatch
+		return
+	}
+	ti := textinput.New()
+	ti.Prompt = prompt
+	ti.SetValue(value)
+	ti.CursorEnd()
+	ti.Focus()
+	m.mBatch = ti
+	m.State = StateBatchInput
+}
+
+func (m *Model) updateBatchInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.State = StateHome
+		return nil
+	case "enter":
+		m.batchArg = strings.Trim(strings.TrimSpace(m.mBatch.Value()), "/")
+		if m.batchOp == batchMove {
+			m.batchArg = m.normalizeCategory(m.batchArg)
+		}
+		if m.batchArg == "" {
+			return nil
+		}
+		// Exporting copies files and changes nothing in the library.
+		if m.batchOp == batchExport {
+			m.State = StateHome
+			return m.runBatch()
+		}
+		m.State = StateConfirmBatch
+		return nil
+	}
+	var cmd tea.Cmd
+	m.mBatch, cmd = m.mBatch.Update(msg)
+	return cmd
+}
+
+func (m *Model) updateConfirmBatch(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "y", "Y":
+		m.State = StateHome
+		return m.runBatch()
+	case "n", "N", "esc":
+		m.State = StateHome
+	}
+	return nil
+}
+
+// runBatch applies the pending operation to every selected snippet and reloads.
+func (m *Model) runBatch() tea.Cmd {
+	list := m.selectedSnippets()
+	op, arg := m.batchOp, m.batchArg
+	repo := m.ctx.Repo()
+	m.clearSelection()
+	return func() tea.Msg {
+		if op == batchExport {
+			n, err := repo.ExportFiles(list, arg)
+			if err != nil {
+				return statusMsg("export failed: " + err.Error())
+			}
+			return statusMsg(fmt.Sprintf("exported %d snippets to %s", n, arg))
+		}
+		var err error
+		for _, s := range list {
+			switch op {
+			case batchDelete:
+				err = repo.Delete(s)
+			case batchMove:
+				_, err = repo.Move(s, arg)
+			case batchTagAdd:
+				if !hasTag(s.Tags, arg) {
+					s.Tags = append(s.Tags, arg)
+					_, err = repo.Update(s)
+				}
+			case batchTagRemove:
+				if hasTag(s.Tags, arg) {
+					s.Tags = removeTag(s.Tags, arg)
+					_, err = repo.Update(s)
+				}
+			}
+			if err != nil {
+				return statusMsg(fmt.Sprintf("error on %s: %v", s.Title, err))
+			}
+		}
+		all, _ := repo.LoadAll()
+		return reloadedMsg(all)
+	}
+}
+
+// copySelected copies the selected snippets concatenated, each under a title header.
+func (m *Model) copySelected() tea.Cmd {
+	var parts []string
+	skipped := 0
+	encrypted := false
+	for _, s := range m.selectedSnippets() {
+		plain, ok := m.plainSnippet(s)
+		if !ok {
+			skipped++
+			continue
+		}
+		encrypted = encrypted || s.Encrypted
+		parts = append(parts, "# "+s.Title+"\n"+strings.TrimRight(snippets.JoinParts(plain), "\n"))
+	}
+	if len(parts) == 0 {
+		m.Status = "selected snippets are encrypted: unlock the vault first (u)"
+		return nil
+	}
+	cmd := m.copyContent(fmt.Sprintf("%d snippets", len(parts)), strings.Join(parts, "\n\n")+"\n", encrypted)
+	if skipped > 0 {
+		m.Status = fmt.Sprintf("%d encrypted snippets skipped", skipped)
+	}
+	return cmd
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func removeTag(tags []string, tag string) []string {
+	var out []string
+	for _, t := range tags {
+		if !strings.EqualFold(t, tag) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (m Model) viewBatchInput() string {
+	body := ui.TitleStyle.Render(fmt.Sprintf("%s %d snippets", m.batchOp, len(m.selected))) +
+		"\n\n" + m.mBatch.View() +
+		"\n\n" + ui.StatusStyle.Render("enter: continue, esc: cancel")
+	return ui.ModalBorder.Render(body)
+}
+
+func (m Model) viewConfirmBatch() string {
+	list := m.selectedSnippets()
+	title := fmt.Sprintf("%s %d snippets?", m.batchOp, len(list))
+	switch m.batchOp {
+	case batchMove:
+		title = fmt.Sprintf("Move %d snippets to %s?", len(list), m.batchArg)
+	case batchTagAdd:
+		title = fmt.Sprintf("Add tag %q to %d snippets?", m.batchArg, len(list))
+	case batchTagRemove:
+		title = fmt.Sprintf("Remove tag %q from %d snippets?", m.batchArg, len(list))
+	}
+	lines := []string{ui.TitleStyle.Render(title), ""}
+	for i, s := range list {
+		if i == batchSummaryMax {
+			lines = append(lines, ui.Theme.Footer.Render(fmt.Sprintf("  … and %d more", len(list)-batchSummaryMax)))
+			break
+		}
+		lines = append(lines, "  "+s.Title+" "+ui.Theme.Footer.Render(s.Category))
+	}
+	if m.batchOp == batchDelete {
+		lines = append(lines, "", ui.ErrorStyle.Render("Files are removed from disk."))
+	}
+	lines = append(lines, "", ui.StatusStyle.Render("y: yes, n/esc: cancel"))
+	return ui.ModalBorder.Render(strings.Join(lines, "\n"))
+}
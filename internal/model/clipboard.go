@@ -0,0 +1,154 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/HrodWolfS/snipster/internal/clip"
+	"github.com/HrodWolfS/snipster/internal/snippets"
+	"github.com/HrodWolfS/snipster/internal/ui"
+)
+
+// Default number of copies kept in the in-app history, overridable with
+// SNIPSTER_CLIP_HISTORY (0 disables it).
+const defaultClipHistory = 10
+
+// copyEntry is one text copied by snipster, with the title of its snippet.
+// Entries holding decrypted content are dropped when the vault locks.
+type copyEntry struct {
+	title, text string
+	encrypted   bool
+}
+
+type pastedMsg struct {
+	text string
+	err  error
+}
+
+func clipHistorySize() int {
+	if n, err := strconv.Atoi(os.Getenv("SNIPSTER_CLIP_HISTORY")); err == nil && n >= 0 {
+		return n
+	}
+	return defaultClipHistory
+}
+
+// copyContent copies text and remembers it in the history, most recent first.
+// encrypted marks text decrypted from the vault.
+func (m *Model) copyContent(title, text string, encrypted bool) tea.Cmd {
+	if n := clipHistorySize(); n > 0 {
+		h := []copyEntry{{title: title, text: text, encrypted: encrypted}}
+		for _, e := range m.copyHistory {
+			if e.text != text && len(h) < n {
+				h = append(h, e)
+			}
+		}
+		m.copyHistory = h
+	}
+	return copyToClipboard(text)
+}
+
+// recordUse counts a use of s, for the usage ordering of exports.
+func (m *Model) recordUse(s snippets.Snippet) tea.Cmd {
+	repo := m.ctx.Repo()
+	return func() tea.Msg {
+		_ = repo.RecordUse(s.ID)
+		return nil
+	}
+}
+
+// pasteClipboard reads the clipboard off the UI goroutine (it may run xclip or tmux).
+func pasteClipboard() tea.Msg {
+	text, err := clip.Paste(clip.MethodFromEnv())
+	return pastedMsg{text: text, err: err}
+}
+
+func (m *Model) handlePasted(msg pastedMsg) {
+	if msg.err != nil {
+		m.Status = "paste failed: " + msg.err.Error()
+		return
+	}
+	if strings.TrimSpace(msg.text) == "" {
+		m.Status = "clipboard is empty"
+		return
+	}
+	m.PrefillCreate(snippets.Snippet{Content: msg.text})
+	m.Status = "new snippet from clipboard"
+}
+
+// PrefillCreate opens the Create modal with the fields of s. A missing language
+// is guessed from the content and a missing category is the current folder.
+func (m *Model) PrefillCreate(s snippets.Snippet) {
+	m.State = StateCreate
+	m.initModalInputs()
+	if s.Language == "" {
+		s.Language = snippets.GuessLanguage(s.Content)
+	}
+	if s.Category == "" {
+		s.Category = m.CurrentPath
+	}
+	m.mTitle.SetValue(s.Title)
+	m.mCategory.SetValue(s.Category)
+	m.mTags.SetValue(strings.Join(s.Tags, ", "))
+	m.mLang.SetValue(s.Language)
+	m.mContent.SetValue(s.Content)
+	m.mDesc.SetValue(s.Description)
+	m.mSource.SetValue(s.SourceURL)
+	m.mAuthor.SetValue(s.Author)
+	m.mLicense.SetValue(s.License)
+	m.mRelated.SetValue(m.relatedRefs(s.Related))
+	m.mAliases.SetValue(strings.Join(s.Aliases, ", "))
+	m.setModalFocus(0)
+}
+
+func (m *Model) openHistory() {
+	if len(m.copyHistory) == 0 {
+		m.Status = "nothing copied yet"
+		return
+	}
+	m.historyIdx = 0
+	m.State = StateHistory
+}
+
+func (m *Model) updateHistory(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc", "q", "H":
+		m.State = StateHome
+	case "up", "k":
+		if m.historyIdx > 0 {
+			m.historyIdx--
+		}
+	case "down", "j":
+		if m.historyIdx < len(m.copyHistory)-1 {
+			m.historyIdx++
+		}
+	case "enter":
+		e := m.copyHistory[m.historyIdx]
+		m.State = StateHome
+		return m.copyContent(e.title, e.text, e.encrypted)
+	}
+	return nil
+}
+
+func (m Model) viewHistory() string {
+	width := max(20, m.Width/2)
+	lines := []string{ui.TitleStyle.Render("Copy history"), ""}
+	for i, e := range m.copyHistory {
+		first, _, _ := strings.Cut(strings.TrimSpace(e.text), "\n")
+		if r := []rune(first); len(r) > width {
+			first = string(r[:width]) + "…"
+		}
+		line := fmt.Sprintf("%2d. %s  %s", i+1, e.title, ui.Theme.Footer.Render(first))
+		if i == m.historyIdx {
+			line = ui.Theme.Tab.Render("› ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", ui.StatusStyle.Render("j/k: select  enter: copy again  esc: close"))
+	return ui.ModalBorder.Render(strings.Join(lines, "\n"))
+}
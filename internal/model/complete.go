@@ -0,0 +1,129 @@
+package model
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+
+	"github.com/HrodWolfS/snipster/internal/ui"
+)
+
+// Autocompletion of the category and language fields of the Create/Edit modal.
+// Tags are completed in tags.go.
+
+// folderPaths returns the path of every category folder, sorted.
+func (m *Model) folderPaths() []string {
+	var out []string
+	var walk func(n *folderNode)
+	walk = func(n *folderNode) {
+		for _, c := range n.Children {
+			out = append(out, c.Path)
+			walk(c)
+		}
+	}
+	if m.folderRoot != nil {
+		walk(m.folderRoot)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// normalizeCategory reuses the spelling of the existing folders matching cat
+// regardless of case, so that "Backend/DB" files into "backend/db". Segments
+// without a folder yet are lowercased.
+func (m *Model) normalizeCategory(cat string) string {
+	node := m.folderRoot
+	var parts []string
+	for _, p := range strings.Split(cat, "/") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		var match *folderNode
+		if node != nil {
+			// An exact match wins; otherwise the first folder in name order, so
+			// that "Backend" and "backend" side by side resolve the same way each run.
+			if match = node.Children[p]; match == nil {
+				names := make([]string, 0, len(node.Children))
+				for name := range node.Children {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					if strings.EqualFold(name, p) {
+						match = node.Children[name]
+						break
+					}
+				}
+			}
+		}
+		if match != nil {
+			p = match.Name
+		} else {
+			p = strings.ToLower(p)
+		}
+		parts = append(parts, p)
+		node = match
+	}
+	return strings.Join(parts, "/")
+}
+
+// newTopCategory returns the top-level folder cat would create, or "" when it
+// already exists.
+func (m *Model) newTopCategory(cat string) string {
+	cat = m.normalizeCategory(cat)
+	if cat == "" || m.folderRoot == nil {
+		return ""
+	}
+	top, _, _ := strings.Cut(cat, "/")
+	if _, ok := m.folderRoot.Children[top]; ok {
+		return ""
+	}
+	return top
+}
+
+// completeField completes the focused modal field with its first suggestion. It
+// returns false when there is nothing to complete, so that tab moves on.
+func (m *Model) completeField() bool {
+	switch m.modalFocus {
+	case 1:
+		return completeInput(&m.mCategory, m.knownCategories)
+	case 2:
+		return m.completeTags()
+	case 3:
+		return completeInput(&m.mLang, m.knownLangs)
+	}
+	return false
+}
+
+func completeInput(ti *textinput.Model, known []string) bool {
+	v := strings.TrimSpace(ti.Value())
+	if v == "" || isKnown(v, known) {
+		return false
+	}
+	sug := suggest(v, known)
+	if len(sug) == 0 {
+		return false
+	}
+	ti.SetValue(sug[0])
+	ti.CursorEnd()
+	return true
+}
+
+// fieldSuggestions renders the suggestions for the focused modal field.
+func (m Model) fieldSuggestions() string {
+	var sug []string
+	switch m.modalFocus {
+	case 1:
+		sug = suggest(strings.TrimSpace(m.mCategory.Value()), m.knownCategories)
+	case 2:
+		return m.tagSuggestions()
+	case 3:
+		sug = suggest(strings.TrimSpace(m.mLang.Value()), m.knownLangs)
+	}
+	if len(sug) == 0 {
+		return ""
+	}
+	return ui.Theme.Footer.Render("  tab: " + strings.Join(sug, "  "))
+}
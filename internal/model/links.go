@@ -0,0 +1,81 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+	"github.com/HrodWolfS/snipster/internal/ui"
+)
+
+// renderLinks lists the related snippets and backlinks of s under the preview
+// and remembers them as jump targets.
+func (m *Model) renderLinks(s snippets.Snippet) string {
+	related, dangling := snippets.ResolveLinks(m.Snippets, s)
+	backlinks := snippets.Backlinks(m.Snippets, s)
+	m.linkTargets = append(append([]snippets.Snippet(nil), related...), backlinks...)
+	return ui.RenderRelated(related, backlinks, dangling)
+}
+
+// titledLinks swaps the [[ref]] links of a description for the titles of the
+// snippets they point to.
+func (m *Model) titledLinks(text string) string {
+	return snippets.ReplaceLinks(text, func(ref string) string {
+		if t, ok := snippets.Find(m.Snippets, ref); ok {
+			return "[[" + t.Title + "]]"
+		}
+		return "[[" + ref + " (missing)]]"
+	})
+}
+
+// jumpTo shows the snippet with the given ID in its folder, leaving any search.
+func (m *Model) jumpTo(id string) {
+	t, ok := snippets.Find(m.Snippets, id)
+	if !ok {
+		return
+	}
+	m.SearchInput.SetValue("")
+	m.SearchInput.Blur()
+	m.SearchActive = false
+	m.CurrentPath = strings.Trim(t.Category, "/")
+	m.applyFilter("")
+	for i, it := range m.VisibleItems {
+		if it.Snippet != nil && it.Snippet.ID == id {
+			m.List.Select(i)
+			break
+		}
+	}
+	m.refreshPreview()
+	m.Status = "→ " + t.Title
+}
+
+// relatedIDs resolves the refs typed in the Related field to snippet IDs.
+func (m *Model) relatedIDs() ([]string, error) {
+	var ids []string
+	for _, ref := range splitTags(m.mRelated.Value()) {
+		t, ok := snippets.Find(m.Snippets, ref)
+		if !ok {
+			return nil, fmt.Errorf("no snippet %q", ref)
+		}
+		if m.State == StateEdit && m.editing != nil && t.ID == m.editing.ID {
+			return nil, fmt.Errorf("a snippet cannot be related to itself")
+		}
+		ids = append(ids, t.ID)
+	}
+	return ids, nil
+}
+
+// relatedRefs shows related IDs as file slugs where the slug finds the same
+// snippet, as slugs are easier to read and type.
+func (m *Model) relatedRefs(ids []string) string {
+	refs := make([]string, len(ids))
+	for i, id := range ids {
+		refs[i] = id
+		if t, ok := snippets.Find(m.Snippets, id); ok {
+			if u, ok := snippets.Find(m.Snippets, t.Slug()); ok && u.ID == t.ID {
+				refs[i] = t.Slug()
+			}
+		}
+	}
+	return strings.Join(refs, ", ")
+}
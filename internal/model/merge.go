@@ -0,0 +1,177 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+	"github.com/HrodWolfS/snipster/internal/ui"
+)
+
+// Rows of the merge view and the side picked for each of them.
+const (
+	mergeRowTitle = iota
+	mergeRowTags
+	mergeRowContent
+	mergeRows
+)
+
+const (
+	pickLeft = iota
+	pickRight
+	pickBoth // tags only: union of both sides
+)
+
+// Lines of content shown per side in the merge view.
+const mergePreviewLines = 12
+
+// openMerge lists the duplicates of the selected snippet and shows the first one side by side.
+func (m *Model) openMerge() {
+	s, ok := m.currentSnippet()
+	if !ok {
+		return
+	}
+	if s.Encrypted {
+		m.Status = "encrypted snippets cannot be merged"
+		return
+	}
+	m.mergeDups = nil
+	for _, d := range snippets.FindDuplicates(m.Snippets, snippets.DefaultSimilarity) {
+		switch s.Path {
+		case d.A.Path:
+			m.mergeDups = append(m.mergeDups, d)
+		case d.B.Path:
+			d.A, d.B = d.B, d.A
+			m.mergeDups = append(m.mergeDups, d)
+		}
+	}
+	if len(m.mergeDups) == 0 {
+		m.Status = "no duplicate of " + s.Title
+		return
+	}
+	m.mergeIdx, m.mergeRow = 0, 0
+	m.mergePick = [mergeRows]int{}
+	m.State = StateMerge
+}
+
+func (m *Model) updateMerge(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc", "q":
+		m.State = StateHome
+		m.mergeDups = nil
+	case "up", "k":
+		m.mergeRow = (m.mergeRow + mergeRows - 1) % mergeRows
+	case "down", "j":
+		m.mergeRow = (m.mergeRow + 1) % mergeRows
+	case "left", "h":
+		m.mergePick[m.mergeRow] = pickLeft
+	case "right", "l":
+		m.mergePick[m.mergeRow] = pickRight
+	case "b":
+		if m.mergeRow == mergeRowTags {
+			m.mergePick[m.mergeRow] = pickBoth
+		}
+	case "s":
+		// Swap sides: the file kept is always the left one.
+		d := &m.mergeDups[m.mergeIdx]
+		d.A, d.B = d.B, d.A
+		for i, p := range m.mergePick {
+			if p != pickBoth {
+				m.mergePick[i] = 1 - p
+			}
+		}
+	case "tab":
+		m.mergeIdx = (m.mergeIdx + 1) % len(m.mergeDups)
+		m.mergePick = [mergeRows]int{}
+	case "enter":
+		d := m.mergeDups[m.mergeIdx]
+		keep, drop := mergedSnippet(d.A, d.B, m.mergePick), d.B
+		m.mergeDups = nil
+		return func() tea.Msg {
+			if _, err := m.ctx.Repo().Merge(keep, drop); err != nil {
+				return statusMsg("error: " + err.Error())
+			}
+			all, _ := m.ctx.Repo().LoadAll()
+			return reloadedMsg(all)
+		}
+	}
+	return nil
+}
+
+// mergedSnippet applies the picked fields to left, whose file is kept.
+func mergedSnippet(left, right snippets.Snippet, pick [mergeRows]int) snippets.Snippet {
+	out := left
+	if pick[mergeRowTitle] == pickRight {
+		out.Title = right.Title
+	}
+	switch pick[mergeRowTags] {
+	case pickRight:
+		out.Tags = right.Tags
+	case pickBoth:
+		out.Tags = unionTags(left.Tags, right.Tags)
+	}
+	if pick[mergeRowContent] == pickRight {
+		out.Content, out.Files, out.Language = right.Content, right.Files, right.Language
+	}
+	return out
+}
+
+func unionTags(a, b []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range append(append([]string(nil), a...), b...) {
+		if k := strings.ToLower(t); !seen[k] {
+			seen[k] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (m Model) viewMerge() string {
+	d := m.mergeDups[m.mergeIdx]
+	colW := max(20, (m.Width-16)/2)
+	side := func(s snippets.Snippet, me int, label string) string {
+		row := func(r int, name, value string) string {
+			picked := m.mergePick[r] == me || m.mergePick[r] == pickBoth
+			mark := "  "
+			if picked {
+				mark = ui.Theme.Status.Render("✓ ")
+			}
+			line := mark + ui.Theme.Footer.Render(name+": ") + value
+			if r == m.mergeRow {
+				line = ui.Theme.Tab.Render("› ") + line
+			} else {
+				line = "  " + line
+			}
+			return line
+		}
+		content := strings.Split(snippets.JoinParts(s), "\n")
+		if len(content) > mergePreviewLines {
+			content = append(content[:mergePreviewLines], "…")
+		}
+		body := strings.Join([]string{
+			ui.Theme.Header.Render(label) + " " + ui.Theme.Footer.Render(s.Path),
+			"",
+			row(mergeRowTitle, "Title", s.Title),
+			row(mergeRowTags, "Tags", "["+strings.Join(s.Tags, ", ")+"]"),
+			row(mergeRowContent, "Content", ui.Theme.Footer.Render(s.Language)),
+			"",
+			strings.Join(content, "\n"),
+		}, "\n")
+		return ui.ModalBorder.Width(colW).Render(body)
+	}
+
+	kind := fmt.Sprintf("%.0f%% similar", d.Similarity*100)
+	if d.Exact {
+		kind = "exact duplicate"
+	}
+	head := ui.TitleStyle.Render("Merge snippets") + "  " + ui.Theme.Status.Render(kind) +
+		ui.Theme.Footer.Render(fmt.Sprintf("  (%d/%d)", m.mergeIdx+1, len(m.mergeDups)))
+	cols := lipgloss.JoinHorizontal(lipgloss.Top, side(d.A, pickLeft, "keep"), " ", side(d.B, pickRight, "trash"))
+	hint := ui.StatusStyle.Render("j/k: field  h/l: pick side  b: both tags  s: swap kept file  tab: next duplicate  enter: merge  esc: cancel")
+	return lipgloss.JoinVertical(lipgloss.Left, head, "", cols, "", hint)
+}
@@ -1,11 +1,11 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
 
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -13,8 +13,11 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	fuzzy "github.com/sahilm/fuzzy"
 
+	"github.com/HrodWolfS/snipster/internal/clip"
+	"github.com/HrodWolfS/snipster/internal/secrets"
 	"github.com/HrodWolfS/snipster/internal/snippets"
 	"github.com/HrodWolfS/snipster/internal/ui"
+	"github.com/HrodWolfS/snipster/internal/vault"
 )
 
 type AppState int
@@ -26,6 +29,13 @@ const (
 	StateCreate
 	StateEdit
 	StateConfirmDelete
+	StateConfirmRun
+	StateUnlock
+	StateMerge
+	StateHistory
+	StateBatchInput
+	StateConfirmBatch
+	StateTags
 )
 
 type AppContext interface {
@@ -48,6 +58,8 @@ type SidebarItem struct {
 	Path    string            // e.g. "frontend" or "frontend/react"
 	Indent  int               // 0=top folder, 1=subfolder, 2=snippet
 	Snippet *snippets.Snippet // nil for folders
+	// Selected marks snippets picked for a batch operation.
+	Selected bool
 }
 
 // folderNode represents a folder in the category tree for sidebar navigation.
@@ -65,10 +77,14 @@ func (i SidebarItem) Title() string {
 		// folder icon + name + slash
 		return ui.Theme.SidebarTitle.Render(indent + "📁 " + i.Name + "/")
 	case SidebarItemSnippet:
+		icon := "📄 "
+		if i.Selected {
+			icon = ui.Theme.Status.Render("✓ ")
+		}
 		if i.Snippet == nil {
-			return indent + "📄 " + i.Name
+			return indent + icon + i.Name
 		}
-		return indent + "📄 " + i.Snippet.Title
+		return indent + icon + i.Snippet.Title
 	default:
 		return indent + i.Name
 	}
@@ -82,7 +98,11 @@ func (i SidebarItem) Description() string {
 		if i.Snippet == nil {
 			return ""
 		}
-		return fmt.Sprintf("%s  [%s]", i.Snippet.Category, strings.Join(i.Snippet.Tags, ", "))
+		desc := fmt.Sprintf("%s  [%s]", i.Snippet.Category, strings.Join(i.Snippet.Tags, ", "))
+		if n := len(i.Snippet.Files); n > 0 {
+			desc += fmt.Sprintf("  %d files", n)
+		}
+		return desc
 	default:
 		return ""
 	}
@@ -125,17 +145,44 @@ type Model struct {
 	mTags     textinput.Model
 	mLang     textinput.Model
 	mContent  textarea.Model
+	mDesc     textarea.Model
+	mSource   textinput.Model
+	mAuthor   textinput.Model
+	mLicense  textinput.Model
+	mRelated  textinput.Model
+	mAliases  textinput.Model
 
 	// Editing target
 	editing *snippets.Snippet
 
-	// Modal focus index: 0=title,1=category,2=tags,3=lang,4=content
+	// Modal focus index: 0=title,1=category,2=tags,3=lang,4=content,5=description,
+	// 6=source,7=author,8=license,9=related,10=aliases
 	modalFocus int
 
 	// Modal field errors
 	mErrTitle    string
 	mErrCategory string
 	mErrContent  string
+	mErrSource   string
+	mErrRelated  string
+	mErrAliases  string
+
+	// Secrets found on last submit; secretsSeen is the content and description they were
+	// found in (NUL-separated), so that a second ctrl+s on unchanged text saves anyway (warn mode).
+	secretFindings []secrets.Finding
+	secretsSeen    string
+
+	// Encrypt toggle of the Create/Edit modal (ctrl+e)
+	mEncrypt bool
+	// Plaintext bundle files of the snippet being edited (not editable in the modal)
+	editFiles []snippets.File
+
+	// Snippets listed under the preview (related, then backlinks), jumped to with 1-9
+	linkTargets []snippets.Snippet
+
+	// Active file tab of the previewed bundle
+	fileTab   int
+	fileTabID string
 
 	// Window size for centering/layout
 	Width  int
@@ -143,6 +190,58 @@ type Model struct {
 
 	// Border accent index for theme toggle
 	BorderIndex int
+
+	// Pick mode: enter selects the current snippet and quits instead of copying.
+	PickMode bool
+	Picked   *snippets.Snippet
+
+	// Run pane: streamed output of the snippet started with `x`
+	Output       viewport.Model
+	runVisible   bool
+	running      bool
+	runCancel    context.CancelFunc
+	runLines     []string
+	runTitle     string
+	runResult    string
+	previewFullH int
+
+	// Vault session: vaultKey is nil while locked. vaultGen invalidates stale lock timers.
+	vaultKey     *vault.Key
+	vaultGen     int
+	vaultNew     bool
+	vaultFirst   string // first entry of a new vault passphrase, awaiting confirmation
+	mPassphrase  textinput.Model
+	mErrPass     string
+	unlockReturn AppState
+
+	// Merge view: duplicates of the selected snippet, the one shown, the focused
+	// field row and the side picked for each row.
+	mergeDups []snippets.Duplicate
+	mergeIdx  int
+	mergeRow  int
+	mergePick [mergeRows]int
+
+	// Texts copied during this session (H re-copies them)
+	copyHistory []copyEntry
+	historyIdx  int
+
+	// Multi-selection (keyed by file path) and the pending batch operation.
+	selected  map[string]bool
+	selAnchor int
+	batchOp   batchOp
+	batchArg  string
+	mBatch    textinput.Model
+
+	// Tag browser (#) and the known tags offered by the modal's autocompletion
+	tagIndex    []snippets.TagCount
+	tagIdx      int
+	tagRenaming bool
+	mTagRename  textinput.Model
+	knownTags   []string
+
+	// Categories and languages offered by the modal's autocompletion
+	knownCategories []string
+	knownLangs      []string
 }
 
 func New(ctx AppContext, initial []snippets.Snippet) Model {
@@ -155,11 +254,13 @@ func New(ctx AppContext, initial []snippets.Snippet) Model {
 		SearchInput:  search,
 		List:         l,
 		Preview:      vp,
+		Output:       viewport.New(60, 10),
 		State:        StateWelcome,
 		BorderIndex:  0,
 		CurrentPath:  "",
 		Fuzzy:        false,
 		SearchActive: false,
+		selAnchor:    -1,
 	}
 	m.rebuildSidebar()
 	m.applyFilter("")
@@ -183,9 +284,26 @@ func (m *Model) initModalInputs() {
 	ta.SetWidth(60)
 	ta.SetHeight(10)
 	m.mContent = ta
+	desc := textarea.New()
+	desc.Placeholder = "when to use it, caveats… (Markdown)"
+	desc.SetWidth(60)
+	desc.SetHeight(4)
+	m.mDesc = desc
+	m.mSource = ui.NewInput("https://… where it comes from")
+	m.mAuthor = ui.NewInput("author")
+	m.mLicense = ui.NewInput("license e.g. MIT, CC BY-SA 4.0")
+	m.mRelated = ui.NewInput("related snippets: ids or slugs, comma-separated")
+	m.mAliases = ui.NewInput("short names e.g. dkp, comma-separated")
 	m.modalFocus = 0
 	m.setModalFocus(0)
 	m.mErrTitle, m.mErrCategory, m.mErrContent = "", "", ""
+	m.mErrSource, m.mErrRelated, m.mErrAliases = "", "", ""
+	m.secretFindings, m.secretsSeen = nil, ""
+	m.mEncrypt = false
+	m.editFiles = nil
+	m.knownTags = snippets.KnownTags(m.Snippets)
+	m.knownCategories = m.folderPaths()
+	m.knownLangs = snippets.KnownLanguages(m.Snippets)
 }
 
 func (m Model) Init() tea.Cmd { return nil }
@@ -210,6 +328,7 @@ func (m *Model) currentSnippet() (snippets.Snippet, bool) {
 // - Empty query: show full hierarchical tree
 // - Non-empty: flat list of matching snippets (no categories)
 func (m *Model) applyFilter(q string) {
+	changed := m.SearchQuery != strings.TrimSpace(q)
 	m.SearchQuery = strings.TrimSpace(q)
 	qq := strings.ToLower(m.SearchQuery)
 	if qq == "" {
@@ -217,21 +336,18 @@ func (m *Model) applyFilter(q string) {
 		m.VisibleItems = m.itemsForFolder(m.CurrentPath)
 	} else {
 		var out []SidebarItem
+		_, byTag := strings.CutPrefix(m.SearchQuery, snippets.TagQueryPrefix)
 		for i := range m.Snippets {
 			s := m.Snippets[i]
 			matches := false
-			if m.Fuzzy {
-				// Use fuzzy on title and category primarily
-				if len(fuzzy.Find(qq, []string{s.Title})) > 0 || len(fuzzy.Find(qq, []string{s.Category})) > 0 {
-					matches = true
-				}
+			if m.Fuzzy && !byTag {
+				// Fuzzy on title and category; a fuzzy match in a long description
+				// would match nearly anything, so it and aliases match as typed.
+				matches = len(fuzzy.Find(qq, []string{s.Title, s.Category})) > 0 ||
+					snippets.HasAlias(s, m.SearchQuery) ||
+					strings.Contains(strings.ToLower(s.Description), qq)
 			} else {
-				if strings.Contains(strings.ToLower(s.Title), qq) ||
-					strings.Contains(strings.ToLower(s.Category), qq) ||
-					strings.Contains(strings.ToLower(s.Content), qq) ||
-					tagsContain(s.Tags, qq) {
-					matches = true
-				}
+				matches = snippets.MatchQuery(s, m.SearchQuery)
 			}
 			if matches {
 				ss := s // local copy for address stability
@@ -246,13 +362,19 @@ func (m *Model) applyFilter(q string) {
 				} else {
 					displayTitle = highlightContainsString(ss.Title, qq)
 				}
-				out = append(out, SidebarItem{
+				item := SidebarItem{
 					Kind:    SidebarItemSnippet,
 					Name:    displayTitle,
 					Path:    ss.Category,
 					Indent:  0,
 					Snippet: &ss,
-				})
+				}
+				// An exact alias match is what the user typed for: list it first.
+				if snippets.HasAlias(ss, m.SearchQuery) {
+					out = append([]SidebarItem{item}, out...)
+				} else {
+					out = append(out, item)
+				}
 			}
 		}
 		m.VisibleItems = out
@@ -261,10 +383,15 @@ func (m *Model) applyFilter(q string) {
 	// feed list items
 	items := make([]list.Item, 0, len(m.VisibleItems))
 	for i := range m.VisibleItems {
-		items = append(items, m.VisibleItems[i])
+		it := &m.VisibleItems[i]
+		it.Selected = it.Snippet != nil && m.selected[it.Snippet.Path]
+		items = append(items, *it)
 	}
 	m.List.SetItems(items)
-	if len(items) > 0 && (m.List.Index() < 0 || m.List.Index() >= len(items)) {
+	// A new query matching an alias exactly selects that snippet; reloads keep
+	// the cursor where the user left it.
+	promoted := changed && len(m.VisibleItems) > 0 && m.VisibleItems[0].Snippet != nil && snippets.HasAlias(*m.VisibleItems[0].Snippet, m.SearchQuery)
+	if len(items) > 0 && (promoted || m.List.Index() < 0 || m.List.Index() >= len(items)) {
 		m.List.Select(0)
 	}
 	m.refreshPreview()
@@ -316,37 +443,52 @@ func highlightFuzzy(s string, idxs []int) string {
 	return b.String()
 }
 
-func tagsContain(tags []string, q string) bool {
-	for _, t := range tags {
-		if strings.Contains(strings.ToLower(t), q) {
-			return true
-		}
-	}
-	return false
-}
-
 func (m *Model) refreshPreview() {
 	s, ok := m.currentSnippet()
 	if !ok {
 		m.Preview.SetContent("No snippet")
 		return
 	}
-	// Render with basic code styling and gutter
-	m.Preview.SetContent(ui.RenderCodeHighlighted(s, m.SearchQuery))
+	m.linkTargets = nil
+	plain, ok := m.plainSnippet(s)
+	if !ok {
+		s.Content, s.Files = "🔒 encrypted — press u to unlock the vault", nil
+		m.Preview.SetContent(ui.RenderCode(s))
+		return
+	}
+	// Bundles start on their first tab whenever another snippet gets selected.
+	if s.ID != m.fileTabID {
+		m.fileTab, m.fileTabID = 0, s.ID
+	}
+	if n := len(plain.Parts()); m.fileTab >= n {
+		m.fileTab = n - 1
+	}
+	// Render with basic code styling and gutter, then the links of the snippet
+	plain.Description = m.titledLinks(plain.Description)
+	content := ui.RenderBundle(plain, m.fileTab, m.SearchQuery)
+	if links := m.renderLinks(s); links != "" {
+		content += "\n" + links
+	}
+	m.Preview.SetContent(content)
 }
 
-// Clipboard helper
+// Clipboard helpers: the status reports where the text really went
+// (system clipboard, OSC 52, tmux buffer or file), or the error.
 func copyToClipboard(content string) tea.Cmd {
-	return func() tea.Msg {
-		_ = clipboard.WriteAll(content)
-		return statusMsg("copied to clipboard")
-	}
+	return copyCmd(content, "")
 }
 
 func copyPathToClipboard(path string) tea.Cmd {
+	return copyCmd(path, "path ")
+}
+
+func copyCmd(text, what string) tea.Cmd {
 	return func() tea.Msg {
-		_ = clipboard.WriteAll(path)
-		return statusMsg("path copied to clipboard")
+		res, err := clip.Copy(text, clip.MethodFromEnv())
+		if err != nil {
+			return statusMsg(what + "copy failed: " + err.Error())
+		}
+		return statusMsg(what + res.String())
 	}
 }
 
@@ -365,8 +507,8 @@ func (m *Model) setModalFocus(idx int) {
 	if idx < 0 {
 		idx = 0
 	}
-	if idx > 4 {
-		idx = 4
+	if idx > 10 {
+		idx = 10
 	}
 	m.modalFocus = idx
 	// Blur all
@@ -375,6 +517,12 @@ func (m *Model) setModalFocus(idx int) {
 	m.mTags.Blur()
 	m.mLang.Blur()
 	m.mContent.Blur()
+	m.mDesc.Blur()
+	m.mSource.Blur()
+	m.mAuthor.Blur()
+	m.mLicense.Blur()
+	m.mRelated.Blur()
+	m.mAliases.Blur()
 	switch idx {
 	case 0:
 		m.mTitle.Focus()
@@ -386,6 +534,18 @@ func (m *Model) setModalFocus(idx int) {
 		m.mLang.Focus()
 	case 4:
 		m.mContent.Focus()
+	case 5:
+		m.mDesc.Focus()
+	case 6:
+		m.mSource.Focus()
+	case 7:
+		m.mAuthor.Focus()
+	case 8:
+		m.mLicense.Focus()
+	case 9:
+		m.mRelated.Focus()
+	case 10:
+		m.mAliases.Focus()
 	}
 }
 
@@ -523,6 +683,7 @@ func (m *Model) findFolder(path string) *folderNode {
 // Validate current modal inputs, set error messages and focus first invalid.
 func (m *Model) validateModal() bool {
 	m.mErrTitle, m.mErrCategory, m.mErrContent = "", "", ""
+	m.mErrSource, m.mErrRelated, m.mErrAliases = "", "", ""
 	title := strings.TrimSpace(m.mTitle.Value())
 	cat := strings.TrimSpace(m.mCategory.Value())
 	content := strings.TrimSpace(m.mContent.Value())
@@ -535,10 +696,27 @@ func (m *Model) validateModal() bool {
 		m.mErrCategory = "Category is required"
 		valid = false
 	}
-	if content == "" {
+	// Bundles may keep all their code in files.
+	if content == "" && len(m.editFiles) == 0 {
 		m.mErrContent = "Content is required"
 		valid = false
 	}
+	if err := snippets.ValidateSourceURL(m.mSource.Value()); err != nil {
+		m.mErrSource = "Source must be an http(s) URL"
+		valid = false
+	}
+	if _, err := m.relatedIDs(); err != nil {
+		m.mErrRelated = err.Error()
+		valid = false
+	}
+	candidate := snippets.Snippet{Aliases: snippets.NormalizeAliases(splitTags(m.mAliases.Value()))}
+	if m.State == StateEdit && m.editing != nil {
+		candidate.ID = m.editing.ID
+	}
+	if err := snippets.CheckAliases(m.Snippets, candidate); err != nil {
+		m.mErrAliases = strings.TrimPrefix(err.Error(), snippets.ErrAliasTaken.Error()+": ")
+		valid = false
+	}
 	if !valid {
 		// Focus first invalid
 		switch {
@@ -548,6 +726,12 @@ func (m *Model) validateModal() bool {
 			m.setModalFocus(1)
 		case m.mErrContent != "":
 			m.setModalFocus(4)
+		case m.mErrSource != "":
+			m.setModalFocus(6)
+		case m.mErrRelated != "":
+			m.setModalFocus(9)
+		case m.mErrAliases != "":
+			m.setModalFocus(10)
 		}
 	}
 	return valid
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// openURL opens raw with the system opener (open, xdg-open or the Windows
+// URL handler) without waiting for the browser. Only http(s) URLs are opened:
+// source URLs also come from synced files and pulled gists, and the Windows
+// handler would run a local path or a file:// URL.
+func openURL(raw string) tea.Cmd {
+	if err := snippets.ValidateSourceURL(raw); err != nil {
+		return func() tea.Msg { return statusMsg("not opened: " + err.Error()) }
+	}
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return func() tea.Msg { return statusMsg("not opened: " + err.Error()) }
+	}
+	target := u.String()
+	return func() tea.Msg {
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", target)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+		default:
+			cmd = exec.Command("xdg-open", target)
+		}
+		if err := cmd.Start(); err != nil {
+			return statusMsg("open failed: " + err.Error())
+		}
+		go func() { _ = cmd.Wait() }()
+		return statusMsg("opened " + target)
+	}
+}
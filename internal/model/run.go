@@ -0,0 +1,140 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/HrodWolfS/snipster/internal/runner"
+	"github.com/HrodWolfS/snipster/internal/snippets"
+	"github.com/HrodWolfS/snipster/internal/ui"
+)
+
+// Keep the output pane bounded for chatty commands.
+const maxRunLines = 5000
+
+// runEventMsg carries one runner event plus the channel to keep listening on.
+type runEventMsg struct {
+	ev runner.Event
+	ch <-chan runner.Event
+}
+
+func waitRun(ch <-chan runner.Event) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return runEventMsg{ev: ev, ch: ch}
+	}
+}
+
+// isDangerous reports whether the snippet is tagged as requiring confirmation before running.
+func isDangerous(s snippets.Snippet) bool {
+	for _, t := range s.Tags {
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "dangerous", "danger", "destructive":
+			return true
+		}
+	}
+	return false
+}
+
+// startRun launches the snippet and opens the output pane.
+func (m *Model) startRun(s snippets.Snippet) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := runner.Start(ctx, s.Language, s.Content)
+	if err != nil {
+		cancel()
+		m.Status = "error: " + err.Error()
+		return nil
+	}
+	m.runCancel = cancel
+	m.running = true
+	m.runTitle = s.Title
+	m.runResult = ""
+	m.runLines = m.runLines[:0]
+	m.Output.SetContent("")
+	m.Output.GotoTop()
+	if !m.runVisible {
+		m.runVisible = true
+		m.resizeRunPane()
+	}
+	m.Status = "running " + s.Title + " (ctrl+c to cancel)"
+	return waitRun(ch)
+}
+
+func (m *Model) handleRunEvent(msg runEventMsg) tea.Cmd {
+	ev := msg.ev
+	if !ev.Done {
+		follow := m.Output.AtBottom()
+		m.runLines = append(m.runLines, ev.Line)
+		if len(m.runLines) > maxRunLines {
+			m.runLines = m.runLines[len(m.runLines)-maxRunLines:]
+		}
+		m.Output.SetContent(strings.Join(m.runLines, "\n"))
+		if follow {
+			m.Output.GotoBottom()
+		}
+		return waitRun(msg.ch)
+	}
+	m.running = false
+	if m.runCancel != nil {
+		m.runCancel()
+		m.runCancel = nil
+	}
+	dur := ev.Duration.Round(time.Millisecond)
+	switch {
+	case errors.Is(ev.Err, context.Canceled):
+		m.runResult = fmt.Sprintf("cancelled after %s", dur)
+	case ev.Err != nil:
+		m.runResult = fmt.Sprintf("error: %v (%s)", ev.Err, dur)
+	default:
+		m.runResult = fmt.Sprintf("exit %d in %s", ev.ExitCode, dur)
+	}
+	m.Status = m.runTitle + ": " + m.runResult
+	return nil
+}
+
+// resizeRunPane splits the preview column between the code preview and the output pane.
+func (m *Model) resizeRunPane() {
+	full := m.previewFullH
+	if full < 1 {
+		full = m.Preview.Height
+	}
+	m.Output.Width = m.Preview.Width
+	if !m.runVisible {
+		m.Preview.Height = full
+		return
+	}
+	// Output box adds its own border (2) and a title line (1).
+	avail := full - 3
+	if avail < 2 {
+		avail = 2
+	}
+	m.Preview.Height = avail / 2
+	if m.Preview.Height < 1 {
+		m.Preview.Height = 1
+	}
+	m.Output.Height = avail - m.Preview.Height
+	if m.Output.Height < 1 {
+		m.Output.Height = 1
+	}
+}
+
+func (m Model) viewRunPane(width int) string {
+	title := "Output: " + m.runTitle
+	if m.running {
+		title += "  " + ui.Theme.Status.Render("running…")
+	} else if m.runResult != "" {
+		title += "  " + ui.Theme.Status.Render(m.runResult)
+	}
+	inner := ui.Theme.PreviewTitle.Render(title) + "\n" + m.Output.View()
+	return ui.Theme.Preview.
+		Width(width).Height(m.Output.Height + 1).
+		Render(inner)
+}
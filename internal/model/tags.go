@@ -0,0 +1,176 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+	"github.com/HrodWolfS/snipster/internal/ui"
+)
+
+// Suggestions shown under an autocompleted field.
+const maxSuggestions = 5
+
+func (m *Model) openTags() {
+	m.tagIndex = snippets.TagIndex(m.Snippets)
+	if len(m.tagIndex) == 0 {
+		m.Status = "no tags yet"
+		return
+	}
+	m.tagIdx = 0
+	m.tagRenaming = false
+	m.State = StateTags
+}
+
+func (m *Model) updateTags(msg tea.KeyMsg) tea.Cmd {
+	if m.tagRenaming {
+		switch msg.String() {
+		case "esc":
+			m.tagRenaming = false
+			return nil
+		case "enter":
+			from := m.tagIndex[m.tagIdx].Tag
+			to := strings.Trim(strings.TrimSpace(m.mTagRename.Value()), "/")
+			if to == "" || to == from {
+				m.tagRenaming = false
+				return nil
+			}
+			all, repo := m.Snippets, m.ctx.Repo()
+			m.State = StateHome
+			return func() tea.Msg {
+				if _, err := repo.RenameTag(all, from, to); err != nil {
+					return statusMsg("error: " + err.Error())
+				}
+				all, _ := repo.LoadAll()
+				return reloadedMsg(all)
+			}
+		}
+		var cmd tea.Cmd
+		m.mTagRename, cmd = m.mTagRename.Update(msg)
+		return cmd
+	}
+	switch msg.String() {
+	case "esc", "q", "#":
+		m.State = StateHome
+	case "up", "k":
+		if m.tagIdx > 0 {
+			m.tagIdx--
+		}
+	case "down", "j":
+		if m.tagIdx < len(m.tagIndex)-1 {
+			m.tagIdx++
+		}
+	case "enter":
+		q := snippets.TagQueryPrefix + m.tagIndex[m.tagIdx].Tag
+		m.State = StateHome
+		m.SearchInput.SetValue(q)
+		m.SearchActive = false
+		m.applyFilter(q)
+	case "r":
+		ti := textinput.New()
+		ti.Prompt = "Rename to: "
+		ti.SetValue(m.tagIndex[m.tagIdx].Tag)
+		ti.CursorEnd()
+		ti.Focus()
+		m.mTagRename = ti
+		m.tagRenaming = true
+	}
+	return nil
+}
+
+func (m Model) viewTags() string {
+	lines := []string{ui.TitleStyle.Render("Tags"), ""}
+	// Keep the cursor visible in long tag lists.
+	height := max(5, m.Height-14)
+	start := max(0, min(m.tagIdx-height/2, len(m.tagIndex)-height))
+	for i := start; i < len(m.tagIndex) && i < start+height; i++ {
+		tc := m.tagIndex[i]
+		name := tc.Tag
+		if tc.Depth > 0 {
+			name = name[strings.LastIndex(name, "/")+1:]
+		}
+		line := fmt.Sprintf("%s#%s %s", strings.Repeat("  ", tc.Depth), name, ui.Theme.Footer.Render(fmt.Sprintf("(%d)", tc.Count)))
+		if i == m.tagIdx {
+			line = ui.Theme.Tab.Render("› ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "")
+	if m.tagRenaming {
+		lines = append(lines, m.mTagRename.View(),
+			ui.StatusStyle.Render("enter: rename everywhere (an existing tag is merged), esc: cancel"))
+	} else {
+		lines = append(lines, ui.StatusStyle.Render("j/k: select  enter: filter  r: rename/merge  esc: close"))
+	}
+	return ui.ModalBorder.Render(strings.Join(lines, "\n"))
+}
+
+// tagToken splits a comma-separated tags value into everything up to the tag
+// being typed, and that tag.
+func tagToken(value string) (head, token string) {
+	i := strings.LastIndex(value, ",")
+	head, token = value[:i+1], value[i+1:]
+	trimmed := strings.TrimLeft(token, " ")
+	return head + token[:len(token)-len(trimmed)], trimmed
+}
+
+// suggest returns the known values starting with token, ignoring case; token
+// itself is left out.
+func suggest(token string, known []string) []string {
+	if token == "" {
+		return nil
+	}
+	var out []string
+	lt := strings.ToLower(token)
+	for _, k := range known {
+		lk := strings.ToLower(k)
+		if lk != lt && strings.HasPrefix(lk, lt) {
+			out = append(out, k)
+			if len(out) == maxSuggestions {
+				break
+			}
+		}
+	}
+	return out
+}
+
+// isKnown reports whether v is one of known, ignoring case.
+func isKnown(v string, known []string) bool {
+	for _, k := range known {
+		if strings.EqualFold(k, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// completeTags completes the tag being typed with the first suggestion. It
+// returns false when there is nothing to complete, so that tab moves on.
+func (m *Model) completeTags() bool {
+	head, token := tagToken(m.mTags.Value())
+	if token == "" || isKnown(token, m.knownTags) {
+		return false
+	}
+	sug := suggest(token, m.knownTags)
+	if len(sug) == 0 {
+		return false
+	}
+	m.mTags.SetValue(head + sug[0])
+	m.mTags.CursorEnd()
+	return true
+}
+
+// tagSuggestions renders the suggestions for the tag being typed.
+func (m Model) tagSuggestions() string {
+	_, token := tagToken(m.mTags.Value())
+	sug := suggest(token, m.knownTags)
+	if len(sug) == 0 {
+		return ""
+	}
+	return ui.Theme.Footer.Render("  tab: " + strings.Join(sug, "  "))
+}
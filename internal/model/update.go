@@ -3,12 +3,15 @@ package model
 import (
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/HrodWolfS/snipster/internal/secrets"
 	"github.com/HrodWolfS/snipster/internal/snippets"
 	"github.com/HrodWolfS/snipster/internal/ui"
+	"github.com/HrodWolfS/snipster/internal/vault"
 )
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -26,6 +29,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		if m.State == StateHome {
+			// Ctrl+C cancels a running snippet; otherwise it quits as before.
+			if msg.String() == "ctrl+c" {
+				if m.running && m.runCancel != nil {
+					m.runCancel()
+					m.Status = "cancelling " + m.runTitle + "…"
+					return m, nil
+				}
+				return m, tea.Quit
+			}
 			// When in explicit search mode, only handle search keys and ESC.
 			if m.SearchActive {
 				switch msg.String() {
@@ -46,7 +58,39 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, tea.Batch(sc, lc)
 				}
 			}
+			// With a selection, a few keys act on every selected snippet.
+			if len(m.selected) > 0 {
+				switch msg.String() {
+				case "esc":
+					m.clearSelection()
+					m.Status = "selection cleared"
+					return m, nil
+				case "d":
+					m.startBatch(batchDelete)
+					return m, nil
+				case "m":
+					m.startBatch(batchMove)
+					return m, nil
+				case "+":
+					m.startBatch(batchTagAdd)
+					return m, nil
+				case "-":
+					m.startBatch(batchTagRemove)
+					return m, nil
+				case "w":
+					m.startBatch(batchExport)
+					return m, nil
+				case "a":
+					return m, m.copySelected()
+				}
+			}
 			switch msg.String() {
+			case " ":
+				m.toggleSelect()
+				return m, nil
+			case "V":
+				m.selectRange()
+				return m, nil
 			case "/":
 				m.SearchActive = true
 				m.SearchInput.Focus()
@@ -122,14 +166,80 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			case "enter":
 				if s, ok := m.currentSnippet(); ok {
-					return m, copyToClipboard(s.Content)
+					plain, ok := m.plainSnippet(s)
+					if !ok {
+						m.openUnlock()
+						return m, nil
+					}
+					// Bundles copy the file of the active tab
+					content := plain.Content
+					if parts := plain.Parts(); m.fileTab < len(parts) {
+						content = parts[m.fileTab].Content
+					}
+					if m.PickMode {
+						plain.Content = content
+						m.Picked = &plain
+						return m, tea.Quit
+					}
+					return m, tea.Batch(m.copyContent(s.Title, content, s.Encrypted), m.recordUse(s))
+				}
+			case "a":
+				// Copy every file of a bundle, concatenated
+				if s, ok := m.currentSnippet(); ok {
+					plain, ok := m.plainSnippet(s)
+					if !ok {
+						m.openUnlock()
+						return m, nil
+					}
+					return m, tea.Batch(m.copyContent(s.Title, snippets.JoinParts(plain), s.Encrypted), m.recordUse(s))
 				}
+				return m, nil
+			case "[", "]":
+				// Switch bundle file tab, counting the tabs of the decrypted
+				// snippet as the preview renders them
+				if s, ok := m.currentSnippet(); ok {
+					plain, ok := m.plainSnippet(s)
+					if !ok {
+						return m, nil
+					}
+					if n := len(plain.Parts()); n > 1 {
+						if msg.String() == "]" {
+							m.fileTab = (m.fileTab + 1) % n
+						} else {
+							m.fileTab = (m.fileTab + n - 1) % n
+						}
+						m.refreshPreview()
+						m.Preview.GotoTop()
+					}
+				}
+				return m, nil
+			case "u":
+				// Lock/unlock the vault of encrypted snippets
+				if m.vaultKey != nil {
+					m.lockVault()
+				} else {
+					m.openUnlock()
+				}
+				return m, nil
 			case "y":
 				if s, ok := m.currentSnippet(); ok {
 					return m, copyPathToClipboard(s.Path)
 				}
+			case "o":
+				if s, ok := m.currentSnippet(); ok {
+					if s.SourceURL == "" {
+						m.Status = "no source URL for this snippet"
+						return m, nil
+					}
+					return m, openURL(s.SourceURL)
+				}
+				return m, nil
 			case "E":
 				if s, ok := m.currentSnippet(); ok {
+					if s.Encrypted {
+						m.Status = "encrypted snippets can only be edited in the modal (e)"
+						return m, nil
+					}
 					path := s.Path
 					return m, func() tea.Msg {
 						ed := os.Getenv("VISUAL")
@@ -149,13 +259,62 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 				return m, nil
+			case "x":
+				if s, ok := m.currentSnippet(); ok {
+					if m.running {
+						m.Status = "a snippet is already running (ctrl+c to cancel)"
+						return m, nil
+					}
+					plain, ok := m.plainSnippet(s)
+					if !ok {
+						m.openUnlock()
+						return m, nil
+					}
+					s = plain
+					if isDangerous(s) {
+						m.State = StateConfirmRun
+						m.editing = &s
+						return m, nil
+					}
+					return m, m.startRun(s)
+				}
+				return m, nil
+			case "X":
+				// Close the output pane once the run is over
+				if m.runVisible && !m.running {
+					m.runVisible = false
+					m.resizeRunPane()
+				}
+				return m, nil
+			case "J":
+				m.Output.ScrollDown(1)
+				return m, nil
+			case "K":
+				m.Output.ScrollUp(1)
+				return m, nil
 			case "n":
 				m.State = StateCreate
 				m.initModalInputs()
+				// New snippets go to the folder being browsed by default
+				m.mCategory.SetValue(m.CurrentPath)
 				m.mTitle.Focus()
 				return m, nil
+			case "N":
+				// New snippet from the clipboard content
+				return m, pasteClipboard
+			case "H":
+				m.openHistory()
+				return m, nil
+			case "#":
+				m.openTags()
+				return m, nil
 			case "e":
 				if s, ok := m.currentSnippet(); ok {
+					plain, ok := m.plainSnippet(s)
+					if !ok {
+						m.openUnlock()
+						return m, nil
+					}
 					m.State = StateEdit
 					m.initModalInputs()
 					m.editing = &s
@@ -163,10 +322,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.mCategory.SetValue(s.Category)
 					m.mTags.SetValue(strings.Join(s.Tags, ", "))
 					m.mLang.SetValue(s.Language)
-					m.mContent.SetValue(s.Content)
+					m.mContent.SetValue(plain.Content)
+					m.mDesc.SetValue(s.Description)
+					m.mSource.SetValue(s.SourceURL)
+					m.mAuthor.SetValue(s.Author)
+					m.mLicense.SetValue(s.License)
+					m.mRelated.SetValue(m.relatedRefs(s.Related))
+					m.mAliases.SetValue(strings.Join(s.Aliases, ", "))
+					m.mEncrypt = s.Encrypted
+					m.editFiles = plain.Files
 					m.mTitle.Focus()
 				}
 				return m, nil
+			case "M":
+				m.openMerge()
+				return m, nil
+			case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+				if i := int(msg.String()[0] - '1'); i < len(m.linkTargets) {
+					m.jumpTo(m.linkTargets[i].ID)
+				}
+				return m, nil
 			case "d":
 				if s, ok := m.currentSnippet(); ok {
 					m.State = StateConfirmDelete
@@ -174,6 +349,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return m, nil
 			case "q":
+				if m.runCancel != nil {
+					m.runCancel()
+				}
 				return m, tea.Quit
 			}
 		} else {
@@ -194,15 +372,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.editing = nil
 					return m, nil
 				case "enter":
-					if m.modalFocus != 4 {
+					if m.modalFocus != 4 && m.modalFocus != 5 {
 						return m.handleSubmit()
 					}
-					// If focus is on content textarea, do not submit here;
-					// fall through to component update so textarea gets Enter.
+					// If focus is on the content or description textarea, do not
+					// submit here; fall through to component update so it gets Enter.
 				case "ctrl+s":
 					// Save from any field, including textarea
 					return m.handleSubmit()
+				case "ctrl+e":
+					m.mEncrypt = !m.mEncrypt
+					return m, nil
+				case "ctrl+r":
+					// Replace detected secrets with placeholders
+					if len(m.secretFindings) > 0 {
+						m.redactSecrets()
+					}
+					return m, nil
 				case "tab":
+					if m.completeField() {
+						return m, nil
+					}
 					// Prevent advancing past required fields when empty
 					if isCurrentRequiredEmpty(&m) {
 						setCurrentRequiredError(&m)
@@ -230,6 +420,43 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.editing = nil
 					return m, nil
 				}
+			case StateUnlock:
+				switch msg.String() {
+				case "esc":
+					m.State = m.unlockReturn
+					m.Status = "vault still locked"
+					return m, nil
+				case "enter":
+					return m, m.submitUnlock()
+				}
+				var pc tea.Cmd
+				m.mPassphrase, pc = m.mPassphrase.Update(msg)
+				return m, pc
+			case StateMerge:
+				return m, m.updateMerge(msg)
+			case StateHistory:
+				return m, m.updateHistory(msg)
+			case StateBatchInput:
+				return m, m.updateBatchInput(msg)
+			case StateConfirmBatch:
+				return m, m.updateConfirmBatch(msg)
+			case StateTags:
+				return m, m.updateTags(msg)
+			case StateConfirmRun:
+				switch msg.String() {
+				case "y", "Y":
+					m.State = StateHome
+					var cmd tea.Cmd
+					if m.editing != nil {
+						cmd = m.startRun(*m.editing)
+					}
+					m.editing = nil
+					return m, cmd
+				case "n", "N", "esc":
+					m.State = StateHome
+					m.editing = nil
+					return m, nil
+				}
 			}
 		}
 
@@ -293,6 +520,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.List.SetSize(sbContentW, paneContentH)
 		m.Preview.Width = pvContentW
 		m.Preview.Height = paneContentH
+		m.previewFullH = paneContentH
+		m.resizeRunPane()
 
 		// Search input width in header
 		siw := contentWidth / 3
@@ -306,6 +535,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.Status = string(msg)
 		return m, nil
 
+	case runEventMsg:
+		return m, m.handleRunEvent(msg)
+
+	case unlockedMsg:
+		return m, m.handleUnlocked(msg)
+
+	case pastedMsg:
+		m.handlePasted(msg)
+		return m, nil
+
+	case lockMsg:
+		if msg.gen == m.vaultGen && m.vaultKey != nil {
+			m.lockVault()
+		}
+		return m, nil
+
 	case reloadedMsg:
 		m.Snippets = sortSnippets([]snippets.Snippet(msg))
 		m.rebuildSidebar()
@@ -349,41 +594,113 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			var taCmd tea.Cmd
 			m.mContent, taCmd = m.mContent.Update(msg)
 			cmd = tea.Batch(cmd, taCmd)
+		case 5:
+			var taCmd tea.Cmd
+			m.mDesc, taCmd = m.mDesc.Update(msg)
+			cmd = tea.Batch(cmd, taCmd)
+		case 6:
+			m.mSource, _ = m.mSource.Update(msg)
+		case 7:
+			m.mAuthor, _ = m.mAuthor.Update(msg)
+		case 8:
+			m.mLicense, _ = m.mLicense.Update(msg)
+		case 9:
+			m.mRelated, _ = m.mRelated.Update(msg)
+		case 10:
+			m.mAliases, _ = m.mAliases.Update(msg)
 		}
 	case StateConfirmDelete:
 		// no sub-components
+	case StateUnlock:
+		var pc tea.Cmd
+		m.mPassphrase, pc = m.mPassphrase.Update(msg)
+		cmd = pc
 	}
 
 	return m, cmd
 }
 
 func (m *Model) handleSubmit() (tea.Model, tea.Cmd) {
-	// Build snippet from modal inputs
-	tags := splitTags(m.mTags.Value())
-	s := snippets.Snippet{
-		Title:    strings.TrimSpace(m.mTitle.Value()),
-		Category: strings.TrimSpace(m.mCategory.Value()),
-		Language: strings.TrimSpace(m.mLang.Value()),
-		Tags:     tags,
-		Content:  m.mContent.Value(),
-	}
+	// Build snippet from modal inputs. Edits start from the stored snippet so that
+	// fields the modal does not show (schema version, unknown fields…) are kept.
+	var s snippets.Snippet
 	if m.State == StateEdit && m.editing != nil {
-		s.ID = m.editing.ID
-		s.Path = m.editing.Path
-		s.CreatedAt = m.editing.CreatedAt
+		s = *m.editing
+		s.Files = m.editFiles
+		s.Encrypted = false
 	}
+	s.Title = strings.TrimSpace(m.mTitle.Value())
+	s.Category = m.normalizeCategory(m.mCategory.Value())
+	s.Language = strings.ToLower(strings.TrimSpace(m.mLang.Value()))
+	s.Tags = splitTags(m.mTags.Value())
+	s.Content = m.mContent.Value()
+	s.Description = strings.TrimSpace(m.mDesc.Value())
+	s.SourceURL = strings.TrimSpace(m.mSource.Value())
+	s.Author = strings.TrimSpace(m.mAuthor.Value())
+	s.License = strings.TrimSpace(m.mLicense.Value())
+	s.Related, _ = m.relatedIDs()
+	s.Aliases = snippets.NormalizeAliases(splitTags(m.mAliases.Value()))
 
 	if !m.validateModal() {
 		m.Status = "Please fix validation errors"
 		return m, nil
 	}
 
+	// Encrypted contents never reach the disk in clear, but the description does.
+	if mode := secrets.ModeFromEnv(); mode != secrets.ModeOff {
+		scanned := s
+		scanned.Encrypted = m.mEncrypt
+		found := snippets.ScanSecrets(scanned)
+		if mode == secrets.ModeBlock && (m.editing == nil || !m.editing.Encrypted || m.mEncrypt) {
+			// Bundle files are saved as stored and the modal cannot edit them:
+			// only the content and description can hold the save back. Files
+			// of a snippet being decrypted are new on disk in clear, so they count.
+			found = slices.DeleteFunc(found, func(f secrets.Finding) bool {
+				return f.Where != "" && f.Where != snippets.WhereDescription
+			})
+		}
+		seen := s.Content + "\x00" + s.Description
+		confirmed := mode == secrets.ModeWarn && m.secretsSeen == seen
+		if len(found) > 0 && !confirmed {
+			m.secretFindings, m.secretsSeen = found, seen
+			if mode == secrets.ModeBlock {
+				m.Status = "Possible secrets found: replace them before saving"
+				for _, f := range found {
+					if f.Where != "" && f.Where != snippets.WhereDescription {
+						m.Status = "Possible secrets in " + f.Where + ": keep the snippet encrypted to save it"
+						break
+					}
+				}
+			} else {
+				m.Status = "Possible secrets found: ctrl+s again to save anyway"
+			}
+			return m, nil
+		}
+	}
+	m.secretFindings, m.secretsSeen = nil, ""
+
+	if m.mEncrypt {
+		if m.vaultKey == nil {
+			m.openUnlock()
+			m.Status = "Unlock the vault to save an encrypted snippet"
+			return m, nil
+		}
+		enc, err := vault.EncryptSnippet(m.vaultKey, s)
+		if err != nil {
+			m.Status = "error: " + err.Error()
+			return m, nil
+		}
+		s = enc
+	}
+
+	// Aliases are checked against the loaded library rather than a new scan.
+	all := m.Snippets
 	return m, func() tea.Msg {
 		var err error
 		if m.State == StateCreate {
-			s, err = m.ctx.Repo().Create(s)
+			s, err = m.ctx.Repo().CreateAmong(all, s)
 		} else {
-			s, err = m.ctx.Repo().Update(s)
+			s, err = m.ctx.Repo().UpdateAmong(all, s)
 		}
 		if err != nil {
 			return statusMsg("error: " + err.Error())
@@ -434,3 +751,50 @@ func setCurrentRequiredError(m *Model) {
 	}
 	m.Status = "Please fill required field"
 }
+
+// secretText returns the text a secret finding points into: the scanned content
+// or description, or a bundle file.
+func (m Model) secretText(where string) string {
+	content, desc, _ := strings.Cut(m.secretsSeen, "\x00")
+	switch where {
+	case "":
+		return content
+	case snippets.WhereDescription:
+		return desc
+	}
+	for _, f := range m.editFiles {
+		if f.Name == where {
+			return f.Content
+		}
+	}
+	return ""
+}
+
+// redactSecrets replaces the secrets found in the content and description with
+// placeholders. Bundle files are not editable in the modal, so their findings stay.
+func (m *Model) redactSecrets() {
+	var content, desc, rest []secrets.Finding
+	for _, f := range m.secretFindings {
+		switch f.Where {
+		case "":
+			content = append(content, f)
+		case snippets.WhereDescription:
+			desc = append(desc, f)
+		default:
+			rest = append(rest, f)
+		}
+	}
+	if len(content) > 0 {
+		m.mContent.SetValue(secrets.Redact(m.mContent.Value(), content))
+	}
+	if len(desc) > 0 {
+		m.mDesc.SetValue(secrets.Redact(strings.TrimSpace(m.mDesc.Value()), desc))
+	}
+	if len(rest) > 0 {
+		m.secretFindings = rest
+		m.Status = "Secrets replaced; bundle files still hold some"
+		return
+	}
+	m.secretFindings, m.secretsSeen = nil, ""
+	m.Status = "Secrets replaced with placeholders"
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+	"github.com/HrodWolfS/snipster/internal/ui"
+	"github.com/HrodWolfS/snipster/internal/vault"
+)
+
+// Default vault session length, overridable with SNIPSTER_VAULT_TIMEOUT (e.g. "15m").
+const defaultVaultTimeout = 5 * time.Minute
+
+type unlockedMsg struct {
+	key *vault.Key
+	err error
+}
+
+// lockMsg fires when the vault session started at generation gen expires.
+type lockMsg struct{ gen int }
+
+func vaultTimeout() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("SNIPSTER_VAULT_TIMEOUT")); err == nil && d > 0 {
+		return d
+	}
+	return defaultVaultTimeout
+}
+
+// plainSnippet returns the snippet with its content and files decrypted if needed.
+// ok is false when the snippet is encrypted and the vault is locked.
+func (m *Model) plainSnippet(s snippets.Snippet) (snippets.Snippet, bool) {
+	if !s.Encrypted {
+		return s, true
+	}
+	if m.vaultKey == nil {
+		return s, false
+	}
+	plain, err := vault.DecryptSnippet(m.vaultKey, s)
+	if err != nil {
+		return s, false
+	}
+	return plain, true
+}
+
+// openUnlock shows the passphrase prompt, returning to the current state afterwards.
+func (m *Model) openUnlock() {
+	m.unlockReturn = m.State
+	if m.unlockReturn == StateWelcome || m.unlockReturn == StateUnlock {
+		m.unlockReturn = StateHome
+	}
+	ti := textinput.New()
+	ti.Prompt = "Passphrase: "
+	ti.EchoMode = textinput.EchoPassword
+	ti.EchoCharacter = '•'
+	ti.CharLimit = 256
+	ti.Focus()
+	m.mPassphrase = ti
+	m.mErrPass = ""
+	m.vaultNew, m.vaultFirst = false, ""
+	if v, err := vault.Open(m.ctx.Repo().Root()); err == nil {
+		m.vaultNew = !v.Initialized()
+	}
+	m.State = StateUnlock
+}
+
+// submitUnlock derives the key off the UI goroutine: the KDF is deliberately slow.
+// A new vault is only created once the passphrase has been typed twice.
+func (m *Model) submitUnlock() tea.Cmd {
+	pass := m.mPassphrase.Value()
+	if m.vaultNew {
+		switch {
+		case pass == "":
+			m.mErrPass = "empty passphrase"
+			return nil
+		case m.vaultFirst == "":
+			m.vaultFirst = pass
+			m.mErrPass = ""
+			m.mPassphrase.SetValue("")
+			m.mPassphrase.Prompt = "Confirm:    "
+			return nil
+		case pass != m.vaultFirst:
+			m.resetPassphrase()
+			m.mErrPass = "passphrases do not match, try again"
+			return nil
+		}
+	}
+	create := m.vaultNew
+	root := m.ctx.Repo().Root()
+	m.Status = "unlocking…"
+	return func() tea.Msg {
+		v, err := vault.Open(root)
+		if err != nil {
+			return unlockedMsg{err: err}
+		}
+		var k *vault.Key
+		if create {
+			k, err = v.Create(pass)
+		} else {
+			k, err = v.Unlock(pass)
+		}
+		return unlockedMsg{key: k, err: err}
+	}
+}
+
+// resetPassphrase clears the prompt and any passphrase waiting for confirmation.
+func (m *Model) resetPassphrase() {
+	m.vaultFirst = ""
+	m.mPassphrase.SetValue("")
+	m.mPassphrase.Prompt = "Passphrase: "
+}
+
+func (m *Model) handleUnlocked(msg unlockedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.mErrPass = msg.err.Error()
+		if errors.Is(msg.err, vault.ErrVaultExists) {
+			// Created meanwhile by another process: unlock it instead.
+			m.vaultNew = false
+		}
+		m.resetPassphrase()
+		m.Status = "vault still locked"
+		return nil
+	}
+	m.vaultKey = msg.key
+	m.vaultGen++
+	m.State = m.unlockReturn
+	m.mPassphrase.SetValue("")
+	m.Status = "vault unlocked"
+	m.refreshPreview()
+	gen := m.vaultGen
+	return tea.Tick(vaultTimeout(), func(time.Time) tea.Msg { return lockMsg{gen: gen} })
+}
+
+// lockVault forgets the key and the decrypted copies kept in the history.
+func (m *Model) lockVault() {
+	m.vaultKey = nil
+	m.vaultGen++
+	var h []copyEntry
+	for _, e := range m.copyHistory {
+		if !e.encrypted {
+			h = append(h, e)
+		}
+	}
+	m.copyHistory = h
+	if m.State == StateHistory {
+		m.State = StateHome
+	}
+	m.Status = "vault locked"
+	m.refreshPreview()
+}
+
+func (m Model) viewUnlock() string {
+	title := "Unlock vault"
+	hint := "enter: unlock, esc: cancel"
+	if m.vaultNew {
+		title = "Create vault passphrase"
+		hint = "enter: set passphrase for this library, esc: cancel"
+		if m.vaultFirst != "" {
+			hint = "type the passphrase again to confirm, esc: cancel"
+		}
+	}
+	body := ui.TitleStyle.Render(title) + "\n\n" + m.mPassphrase.View()
+	if m.mErrPass != "" {
+		body += "\n" + ui.ErrorStyle.Render(m.mErrPass)
+	}
+	body += "\n\n" + ui.StatusStyle.Render(hint)
+	return ui.ModalBorder.Render(body)
+}
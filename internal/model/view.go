@@ -6,6 +6,7 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 
+	"github.com/HrodWolfS/snipster/internal/secrets"
 	"github.com/HrodWolfS/snipster/internal/ui"
 )
 
@@ -41,6 +42,34 @@ func (m Model) View() string {
 		base := m.viewLayout()
 		modal := m.viewConfirmDelete()
 		return m.overlayModal(base, modal)
+	case StateConfirmRun:
+		base := m.viewLayout()
+		modal := m.viewConfirmRun()
+		return m.overlayModal(base, modal)
+	case StateUnlock:
+		base := m.viewLayout()
+		modal := m.viewUnlock()
+		return m.overlayModal(base, modal)
+	case StateMerge:
+		base := m.viewLayout()
+		modal := m.viewMerge()
+		return m.overlayModal(base, modal)
+	case StateHistory:
+		base := m.viewLayout()
+		modal := m.viewHistory()
+		return m.overlayModal(base, modal)
+	case StateBatchInput:
+		base := m.viewLayout()
+		modal := m.viewBatchInput()
+		return m.overlayModal(base, modal)
+	case StateConfirmBatch:
+		base := m.viewLayout()
+		modal := m.viewConfirmBatch()
+		return m.overlayModal(base, modal)
+	case StateTags:
+		base := m.viewLayout()
+		modal := m.viewTags()
+		return m.overlayModal(base, modal)
 	default:
 		return m.viewLayout()
 	}
@@ -152,14 +181,30 @@ func (m Model) viewLayout() string {
 	sidebarView := ui.Theme.Sidebar.
 		Width(sbContentW).
 		Render(sidebarContent)
-	previewView := ui.Theme.Preview.
-		Width(pvContentW).Height(paneContentH).
-		Render(previewInner)
+	var previewView string
+	if m.runVisible {
+		// Preview and output pane share the column; heights come from resizeRunPane.
+		previewView = lipgloss.JoinVertical(lipgloss.Left,
+			ui.Theme.Preview.Width(pvContentW).Height(m.Preview.Height).Render(previewInner),
+			m.viewRunPane(pvContentW),
+		)
+	} else {
+		previewView = ui.Theme.Preview.
+			Width(pvContentW).Height(paneContentH).
+			Render(previewInner)
+	}
 	gapStr := strings.Repeat(" ", gap)
 	body := lipgloss.JoinHorizontal(lipgloss.Top, sidebarView, gapStr, previewView)
 
 	// Footer: key help
-	help := ui.Theme.Footer.Render("/ search  ? help  j/k,↑/↓ navigate  enter copy  n new  e edit  d delete  q quit")
+	keys := "/ search  ? help  j/k,↑/↓ navigate  enter copy  x run  n new  e edit  d delete  q quit"
+	if len(m.selected) > 0 {
+		keys = fmt.Sprintf("%d selected: space toggle  V range  d delete  m move  +/- tag  w export  a copy all  esc clear", len(m.selected))
+	}
+	if m.PickMode {
+		keys = "/ search  ? help  j/k,↑/↓ navigate  enter pick  q cancel"
+	}
+	help := ui.Theme.Footer.Render(keys)
 
 	inner := lipgloss.JoinVertical(lipgloss.Left, head, body, help)
 	return ui.Theme.Frame.Render(inner)
@@ -175,6 +220,13 @@ func (m Model) viewCreateEdit(action string) string {
 	catLine := "Category*: " + m.mCategory.View()
 	if m.mErrCategory != "" {
 		catLine += "\n" + ui.ErrorStyle.Render(m.mErrCategory)
+	} else if top := m.newTopCategory(m.mCategory.Value()); top != "" {
+		catLine += "\n" + ui.ErrorStyle.Render(fmt.Sprintf("⚠ new top-level category %q", top))
+	}
+	if m.modalFocus == 1 {
+		if sug := m.fieldSuggestions(); sug != "" {
+			catLine += "\n" + sug
+		}
 	}
 
 	contentHeader := "Content*:"
@@ -182,21 +234,98 @@ func (m Model) viewCreateEdit(action string) string {
 	if m.mErrContent != "" {
 		contentBlock += "\n" + ui.ErrorStyle.Render(m.mErrContent)
 	}
+	if len(m.secretFindings) > 0 {
+		contentBlock += "\n" + m.viewSecretFindings()
+	}
+
+	tagsLine := "Tags: " + m.mTags.View()
+	if m.modalFocus == 2 {
+		if sug := m.fieldSuggestions(); sug != "" {
+			tagsLine += "\n" + sug
+		}
+	}
+
+	langLine := "Language: " + m.mLang.View()
+	if m.modalFocus == 3 {
+		if sug := m.fieldSuggestions(); sug != "" {
+			langLine += "\n" + sug
+		}
+	}
+
+	descBlock := "Description:\n" + m.mDesc.View()
+
+	sourceLine := "Source: " + m.mSource.View()
+	if m.mErrSource != "" {
+		sourceLine += "\n" + ui.ErrorStyle.Render(m.mErrSource)
+	}
+	authorLine := "Author: " + m.mAuthor.View()
+	licenseLine := "License: " + m.mLicense.View()
+	aliasesLine := "Aliases: " + m.mAliases.View()
+	if m.mErrAliases != "" {
+		aliasesLine += "\n" + ui.ErrorStyle.Render(m.mErrAliases)
+	}
+	relatedLine := "Related: " + m.mRelated.View()
+	if m.mErrRelated != "" {
+		relatedLine += "\n" + ui.ErrorStyle.Render(m.mErrRelated)
+	}
+
+	encryptLine := "Encrypted: no"
+	if m.mEncrypt {
+		encryptLine = "Encrypted: " + ui.Theme.Status.Render("yes 🔒")
+	}
 
 	form := strings.Join([]string{
 		ui.TitleStyle.Render(fmt.Sprintf("%s Snippet", action)),
 		"",
 		titleLine,
 		catLine,
-		"Tags: " + m.mTags.View(),
-		"Language: " + m.mLang.View(),
+		tagsLine,
+		langLine,
 		contentHeader,
 		contentBlock,
-		ui.StatusStyle.Render("ctrl+s: save, esc: cancel (enter in content adds newline)"),
+		descBlock,
+		sourceLine,
+		authorLine,
+		licenseLine,
+		relatedLine,
+		aliasesLine,
+		encryptLine,
+		ui.StatusStyle.Render("ctrl+s: save, ctrl+e: toggle encryption, esc: cancel (enter in content or description adds newline)"),
 	}, "\n")
 	return ui.ModalBorder.Render(form)
 }
 
+// viewSecretFindings lists the offending lines with the suspected value highlighted.
+func (m Model) viewSecretFindings() string {
+	const maxShown = 5
+	out := []string{ui.ErrorStyle.Render("⚠ Possible secrets:")}
+	for i, f := range m.secretFindings {
+		if i == maxShown {
+			out = append(out, ui.Theme.Footer.Render(fmt.Sprintf("  … %d more", len(m.secretFindings)-maxShown)))
+			break
+		}
+		lines := strings.Split(m.secretText(f.Where), "\n")
+		ln := ""
+		if f.Line-1 < len(lines) {
+			ln = lines[f.Line-1]
+		}
+		if f.End <= len(ln) {
+			ln = ln[:f.Start] + ui.ErrorStyle.Underline(true).Render(ln[f.Start:f.End]) + ln[f.End:]
+		}
+		at := fmt.Sprintf("L%d", f.Line)
+		if f.Where != "" {
+			at = f.Where + " " + at
+		}
+		out = append(out, fmt.Sprintf("  %s %s: %s", at, ui.Theme.Footer.Render(f.Kind), ln))
+	}
+	hint := "ctrl+r: replace with placeholders  ctrl+s: save anyway"
+	if secrets.ModeFromEnv() == secrets.ModeBlock {
+		hint = "ctrl+r: replace with placeholders (saving is blocked)"
+	}
+	out = append(out, ui.StatusStyle.Render(hint))
+	return strings.Join(out, "\n")
+}
+
 func (m Model) viewConfirmDelete() string {
 	name := ""
 	if m.editing != nil {
@@ -208,6 +337,18 @@ func (m Model) viewConfirmDelete() string {
 	return ui.ModalBorder.Render(msg)
 }
 
+func (m Model) viewConfirmRun() string {
+	name, lang := "", ""
+	if m.editing != nil {
+		name, lang = m.editing.Title, m.editing.Language
+	}
+	msg := ui.TitleStyle.Render("Run dangerous snippet?") +
+		"\n\n" + name + " " + ui.Theme.Footer.Render("("+lang+")") +
+		"\n\n" + ui.ErrorStyle.Render("This snippet is tagged as dangerous.") +
+		"\n\n" + ui.StatusStyle.Render("y: run, n/esc: cancel")
+	return ui.ModalBorder.Render(msg)
+}
+
 func (m Model) viewHelp() string {
 	help := strings.Join([]string{
 		ui.TitleStyle.Render("Keyboard Shortcuts"),
@@ -220,15 +361,37 @@ func (m Model) viewHelp() string {
 		ui.Theme.Header.Render("Search"),
 		"  /             Activate search bar",
 		"  f             Toggle fuzzy search",
+		"  #             Tag browser (enter filters, r renames/merges)",
+		"  /#tag         Filter on a tag and its children (cloud → cloud/aws)",
 		"  Esc           Clear search / Exit modal",
 		"",
 		ui.Theme.Header.Render("Actions"),
 		"  Enter         Copy snippet content to clipboard",
+		"  [ ]           Switch file tab of a multi-file snippet",
+		"  a             Copy all files of a multi-file snippet",
 		"  y             Copy file path to clipboard",
+		"  o             Open the source URL in the browser",
 		"  n             Create new snippet",
+		"  N             Create new snippet from clipboard",
+		"  H             Copy history (copy a previous snippet again)",
 		"  e             Edit selected snippet",
 		"  d             Delete selected snippet",
+		"  M             Merge selected snippet with a duplicate",
+		"  1-9           Jump to a related or linking snippet",
 		"  E             Open snippet in external editor ($EDITOR)",
+		"  x             Run snippet (output pane, ctrl+c cancels)",
+		"  X             Close output pane",
+		"  u             Unlock/lock the vault of encrypted snippets",
+		"  J K           Scroll output pane",
+		"",
+		ui.Theme.Header.Render("Selection"),
+		"  Space         Select/unselect snippet",
+		"  V             Select range from last selected",
+		"  d m           Delete / move selected snippets",
+		"  + -           Add / remove a tag on selected snippets",
+		"  w             Export selected snippet files to a folder",
+		"  a             Copy selected snippets concatenated",
+		"  Esc           Clear selection",
 		"",
 		ui.Theme.Header.Render("Interface"),
 		"  t             Cycle border theme colors",
@@ -268,6 +431,9 @@ func (m Model) headerStatus() string {
 	if q := strings.TrimSpace(m.SearchInput.Value()); q != "" {
 		parts = append(parts, "filter: "+q)
 	}
+	if m.vaultKey != nil {
+		parts = append(parts, "🔓")
+	}
 	if m.Status != "" {
 		parts = append(parts, m.Status)
 	}
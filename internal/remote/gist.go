@@ -0,0 +1,246 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// DefaultGistURL is the API root of GitHub.
+const DefaultGistURL = "https://api.github.com"
+
+// Gists listed per page; GitHub allows up to 100.
+const gistPage = 100
+
+// Gist talks to the GitHub Gist API, or to any server speaking the same
+// protocol at another base URL (GitHub Enterprise, a local fake…).
+type Gist struct {
+	base   string
+	token  string
+	client *http.Client
+}
+
+// NewGist returns a Gist provider for the API at base (DefaultGistURL when
+// empty), authenticated with token.
+func NewGist(base, token string) *Gist {
+	if base == "" {
+		base = DefaultGistURL
+	}
+	return &Gist{base: strings.TrimRight(base, "/"), token: token, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (g *Gist) Name() string { return "gist" }
+
+type gistFile struct {
+	Filename  string `json:"filename,omitempty"`
+	Language  string `json:"language,omitempty"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"`
+	RawURL    string `json:"raw_url,omitempty"`
+}
+
+type gistDoc struct {
+	ID          string               `json:"id,omitempty"`
+	Description string               `json:"description"`
+	Public      bool                 `json:"public"`
+	HTMLURL     string               `json:"html_url,omitempty"`
+	Files       map[string]*gistFile `json:"files"`
+}
+
+func (g *Gist) List(ctx context.Context) ([]Item, error) {
+	var out []Item
+	for page := 1; ; page++ {
+		var docs []gistDoc
+		if err := g.do(ctx, http.MethodGet, fmt.Sprintf("/gists?per_page=%d&page=%d", gistPage, page), nil, &docs); err != nil {
+			return nil, err
+		}
+		for _, d := range docs {
+			out = append(out, Item{ID: d.ID, Title: gistTitle(d), URL: d.HTMLURL})
+		}
+		if len(docs) < gistPage {
+			return out, nil
+		}
+	}
+}
+
+func (g *Gist) Pull(ctx context.Context, id string) (snippets.Snippet, error) {
+	var d gistDoc
+	if err := g.do(ctx, http.MethodGet, "/gists/"+id, nil, &d); err != nil {
+		return snippets.Snippet{}, err
+	}
+	names := make([]string, 0, len(d.Files))
+	for name, f := range d.Files {
+		// Large files are only listed: their content is behind raw_url.
+		if f.Truncated && f.RawURL != "" {
+			content, err := g.raw(ctx, f.RawURL)
+			if err != nil {
+				return snippets.Snippet{}, err
+			}
+			f.Content = content
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s := snippets.Snippet{Title: gistTitle(d), SourceURL: d.HTMLURL, Remotes: map[string]string{g.Name(): d.ID}}
+	for _, name := range names {
+		f := d.Files[name]
+		lang := snippets.LanguageFor(name)
+		if lang == "" {
+			lang = strings.ToLower(f.Language)
+		}
+		s.Files = append(s.Files, snippets.File{Name: name, Language: lang, Content: f.Content})
+	}
+	// A single file is the snippet content.
+	if len(s.Files) == 1 {
+		s.Content, s.Language, s.Files = s.Files[0].Content, s.Files[0].Language, nil
+	}
+	return s, nil
+}
+
+func (g *Gist) Push(ctx context.Context, s snippets.Snippet, public bool) (string, error) {
+	if s.Encrypted {
+		return "", fmt.Errorf("%s is encrypted: it is never pushed", s.Title)
+	}
+	id := s.Remotes[g.Name()]
+	var cur gistDoc
+	if id != "" {
+		if err := g.do(ctx, http.MethodGet, "/gists/"+id, nil, &cur); err != nil {
+			return "", err
+		}
+	}
+	doc := gistDoc{Description: s.Title, Public: public, Files: map[string]*gistFile{}}
+	parts := s.Parts()
+	if s.Content != "" || len(s.Files) == 0 {
+		parts[0].Name = mainFileName(s, cur)
+	}
+	for _, p := range parts {
+		doc.Files[p.Name] = &gistFile{Content: p.Content}
+	}
+	if id == "" {
+		var created gistDoc
+		if err := g.do(ctx, http.MethodPost, "/gists", doc, &created); err != nil {
+			return "", err
+		}
+		return created.ID, nil
+	}
+	// Files gone from the snippet are deleted from the gist by sending null.
+	patch := map[string]any{"description": doc.Description}
+	files := map[string]any{}
+	for name, f := range doc.Files {
+		files[name] = f
+	}
+	for name := range cur.Files {
+		if _, ok := doc.Files[name]; !ok {
+			files[name] = nil
+		}
+	}
+	patch["files"] = files
+	if err := g.do(ctx, http.MethodPatch, "/gists/"+id, patch, nil); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// mainFileName names the file holding the content of s in the gist cur: the
+// name it already has there, so that a pulled gist keeps its file name, or one
+// derived from the snippet for a new gist.
+func mainFileName(s snippets.Snippet, cur gistDoc) string {
+	var left []string
+	for name := range cur.Files {
+		if !slices.ContainsFunc(s.Files, func(f snippets.File) bool { return f.Name == name }) {
+			left = append(left, name)
+		}
+	}
+	if len(left) == 1 {
+		return left[0]
+	}
+	return snippets.FileNameFor(s)
+}
+
+// gistTitle is the description of a gist, or its first file name.
+func gistTitle(d gistDoc) string {
+	if t := strings.TrimSpace(d.Description); t != "" {
+		return t
+	}
+	names := make([]string, 0, len(d.Files))
+	for name := range d.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		return names[0]
+	}
+	return d.ID
+}
+
+// do sends a JSON request to the API and decodes the answer into out.
+func (g *Gist) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, g.base+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		if e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+		return fmt.Errorf("gist: %s %s: %d %s", method, path, resp.StatusCode, e.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// raw downloads the full content of a truncated file.
+func (g *Gist) raw(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	if g.token != "" && strings.HasPrefix(url, g.base) {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("gist: GET %s: %d", url, resp.StatusCode)
+	}
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
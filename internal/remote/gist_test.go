@@ -0,0 +1,259 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// fakeGists is an in-memory Gist API. Files longer than truncateAt are listed
+// truncated, their content only served at raw_url.
+type fakeGists struct {
+	t          *testing.T
+	srv        *httptest.Server
+	mu         sync.Mutex
+	gists      map[string]*gistDoc
+	order      []string
+	truncateAt int
+	patches    []map[string]any
+}
+
+func newFakeGists(t *testing.T) *fakeGists {
+	f := &fakeGists{t: t, gists: map[string]*gistDoc{}, truncateAt: 1 << 20}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /gists", f.list)
+	mux.HandleFunc("POST /gists", f.create)
+	mux.HandleFunc("GET /gists/{id}", f.get)
+	mux.HandleFunc("PATCH /gists/{id}", f.patch)
+	mux.HandleFunc("GET /raw/{id}/{name}", f.raw)
+	f.srv = httptest.NewServer(f.auth(mux))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeGists) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *fakeGists) add(d gistDoc) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d.ID = fmt.Sprintf("g%d", len(f.order)+1)
+	d.HTMLURL = "https://gist.example/" + d.ID
+	for name, file := range d.Files {
+		file.Filename = name
+	}
+	f.gists[d.ID] = &d
+	f.order = append(f.order, d.ID)
+	return d.ID
+}
+
+// view is the gist as the API shows it, with long files truncated.
+func (f *fakeGists) view(d *gistDoc) gistDoc {
+	out := *d
+	out.Files = map[string]*gistFile{}
+	for name, file := range d.Files {
+		c := *file
+		if len(c.Content) > f.truncateAt {
+			c.Content, c.Truncated = c.Content[:f.truncateAt], true
+			c.RawURL = f.srv.URL + "/raw/" + d.ID + "/" + name
+		}
+		out.Files[name] = &c
+	}
+	return out
+}
+
+func (f *fakeGists) list(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var page, per int
+	fmt.Sscan(r.URL.Query().Get("page"), &page)
+	fmt.Sscan(r.URL.Query().Get("per_page"), &per)
+	docs := []gistDoc{}
+	for i := (page - 1) * per; i < len(f.order) && i < page*per; i++ {
+		docs = append(docs, f.view(f.gists[f.order[i]]))
+	}
+	json.NewEncoder(w).Encode(docs)
+}
+
+func (f *fakeGists) get(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.gists[r.PathValue("id")]
+	if !ok {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(f.view(d))
+}
+
+func (f *fakeGists) raw(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.gists[r.PathValue("id")]
+	if !ok || d.Files[r.PathValue("name")] == nil {
+		http.NotFound(w, r)
+		return
+	}
+	fmt.Fprint(w, d.Files[r.PathValue("name")].Content)
+}
+
+func (f *fakeGists) create(w http.ResponseWriter, r *http.Request) {
+	var d gistDoc
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		f.t.Errorf("create: %v", err)
+	}
+	id := f.add(d)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(f.view(f.gists[id]))
+}
+
+func (f *fakeGists) patch(w http.ResponseWriter, r *http.Request) {
+	var p map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		f.t.Errorf("patch: %v", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.gists[r.PathValue("id")]
+	if !ok {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		return
+	}
+	f.patches = append(f.patches, p)
+	if desc, ok := p["description"].(string); ok {
+		d.Description = desc
+	}
+	files, _ := p["files"].(map[string]any)
+	for name, v := range files {
+		if v == nil {
+			delete(d.Files, name)
+			continue
+		}
+		content, _ := v.(map[string]any)["content"].(string)
+		d.Files[name] = &gistFile{Filename: name, Content: content}
+	}
+	json.NewEncoder(w).Encode(f.view(d))
+}
+
+func TestGistList(t *testing.T) {
+	f := newFakeGists(t)
+	for i := range gistPage + 2 {
+		f.add(gistDoc{Description: fmt.Sprintf("gist %d", i), Files: map[string]*gistFile{"a.sh": {Content: "echo"}}})
+	}
+	f.add(gistDoc{Files: map[string]*gistFile{"b.py": {Content: "print()"}, "a.py": {Content: "pass"}}})
+
+	items, err := NewGist(f.srv.URL, "tok").List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != gistPage+3 {
+		t.Fatalf("listed %d gists, want %d (all pages)", len(items), gistPage+3)
+	}
+	// Without a description, the first file names the gist.
+	if last := items[len(items)-1]; last.Title != "a.py" || last.URL == "" {
+		t.Errorf("last item %+v", last)
+	}
+	if _, err := NewGist(f.srv.URL, "bad").List(context.Background()); err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("bad token: err %v", err)
+	}
+}
+
+func TestGistPull(t *testing.T) {
+	f := newFakeGists(t)
+	f.truncateAt = 10
+	long := strings.Repeat("x", 50) + "\n"
+	single := f.add(gistDoc{Description: "deploy", Files: map[string]*gistFile{"deploy.sh": {Content: long}}})
+	multi := f.add(gistDoc{Description: "app", Files: map[string]*gistFile{"main.go": {Content: "package main\n"}, "Makefile": {Content: "all:\n"}}})
+	g := NewGist(f.srv.URL, "tok")
+
+	s, err := g.Pull(context.Background(), single)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Content != long {
+		t.Errorf("truncated file: content %q, want the raw_url content", s.Content)
+	}
+	if s.Title != "deploy" || s.Language != "bash" || s.Remotes["gist"] != single || s.SourceURL != "https://gist.example/"+single {
+		t.Errorf("single file gist: %+v", s)
+	}
+
+	s, err = g.Pull(context.Background(), multi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Content != "" || len(s.Files) != 2 || s.Files[0].Name != "Makefile" || s.Files[1].Name != "main.go" {
+		t.Errorf("bundle gist: content %q, files %+v", s.Content, s.Files)
+	}
+}
+
+func TestGistPush(t *testing.T) {
+	f := newFakeGists(t)
+	g := NewGist(f.srv.URL, "tok")
+	ctx := context.Background()
+
+	s := snippets.Snippet{Title: "app", Files: []snippets.File{
+		{Name: "main.go", Content: "package main\n"},
+		{Name: "go.mod", Content: "module app\n"},
+	}}
+	id, err := g.Push(ctx, s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := f.gists[id]; d == nil || d.Description != "app" || len(d.Files) != 2 {
+		t.Fatalf("created gist %+v", d)
+	}
+
+	// Update: a file removed from the bundle is deleted from the gist.
+	s.Remotes = map[string]string{"gist": id}
+	s.Files = []snippets.File{{Name: "main.go", Content: "package main\n\nfunc main() {}\n"}}
+	if got, err := g.Push(ctx, s, false); err != nil || got != id {
+		t.Fatalf("update: id %q, err %v", got, err)
+	}
+	files := f.patches[len(f.patches)-1]["files"].(map[string]any)
+	if v, ok := files["go.mod"]; !ok || v != nil {
+		t.Errorf("go.mod not deleted: %v", files)
+	}
+	if d := f.gists[id]; len(d.Files) != 1 || d.Files["main.go"].Content != s.Files[0].Content {
+		t.Errorf("updated gist files %+v", d.Files)
+	}
+
+	if _, err := g.Push(ctx, snippets.Snippet{Title: "secret", Content: "v1:abc", Encrypted: true}, false); err == nil {
+		t.Error("encrypted snippet pushed")
+	}
+}
+
+func TestGistPushKeepsPulledFileName(t *testing.T) {
+	f := newFakeGists(t)
+	id := f.add(gistDoc{Description: "deploy", Files: map[string]*gistFile{"deploy-prod.sh": {Content: "echo v1\n"}}})
+	g := NewGist(f.srv.URL, "tok")
+	ctx := context.Background()
+
+	s, err := g.Pull(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Category, s.Content = "gists", "echo v2\n"
+	if _, err := g.Push(ctx, s, false); err != nil {
+		t.Fatal(err)
+	}
+	d := f.gists[id]
+	if len(d.Files) != 1 || d.Files["deploy-prod.sh"] == nil || d.Files["deploy-prod.sh"].Content != "echo v2\n" {
+		t.Errorf("gist files after push %v", d.Files)
+	}
+}
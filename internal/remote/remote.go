@@ -0,0 +1,62 @@
+// Package remote syncs snippets with remote snippet hosts. Each host is a
+// Provider; the ID of a snippet on a host is kept in Snippet.Remotes under the
+// provider name, so that pushing again updates the same remote snippet.
+package remote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// Provider is a remote snippet host.
+type Provider interface {
+	// Name is the key of the provider in Snippet.Remotes.
+	Name() string
+	// List returns the snippets of the authenticated user, without content.
+	List(ctx context.Context) ([]Item, error)
+	// Pull fetches one remote snippet. The result has Remotes set, but no
+	// category, ID or path.
+	Pull(ctx context.Context, id string) (snippets.Snippet, error)
+	// Push creates s remotely, or updates it when s already has a remote ID
+	// for this provider, and returns the remote ID.
+	Push(ctx context.Context, s snippets.Snippet, public bool) (string, error)
+}
+
+// Item is a remote snippet as listed by a provider.
+type Item struct {
+	ID    string
+	Title string
+	URL   string
+}
+
+// Providers lists the names accepted by New.
+var Providers = []string{"gist"}
+
+// New returns the provider called name, configured from the environment:
+//
+//	gist: SNIPSTER_GIST_TOKEN (or GITHUB_TOKEN), SNIPSTER_GIST_URL
+func New(name string) (Provider, error) {
+	switch name {
+	case "gist":
+		token := os.Getenv("SNIPSTER_GIST_TOKEN")
+		if token == "" {
+			token = os.Getenv("GITHUB_TOKEN")
+		}
+		return NewGist(os.Getenv("SNIPSTER_GIST_URL"), token), nil
+	}
+	return nil, fmt.Errorf("unknown provider %q (want %s)", name, strings.Join(Providers, ", "))
+}
+
+// Find returns the snippet of all stored at the remote id of provider.
+func Find(all []snippets.Snippet, provider, id string) (snippets.Snippet, bool) {
+	for _, s := range all {
+		if s.Remotes[provider] == id {
+			return s, true
+		}
+	}
+	return snippets.Snippet{}, false
+}
@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Default interpreters per snippet language. The snippet content is fed on stdin.
+// Override with SNIPSTER_RUN_<LANG>, e.g. SNIPSTER_RUN_BASH="bash -eu".
+var defaultInterpreters = map[string][]string{
+	"sh":         {"sh"},
+	"bash":       {"bash"},
+	"shell":      {"sh"},
+	"zsh":        {"zsh"},
+	"fish":       {"fish"},
+	"python":     {"python3", "-"},
+	"py":         {"python3", "-"},
+	"js":         {"node", "-"},
+	"javascript": {"node", "-"},
+	"ruby":       {"ruby", "-"},
+	"perl":       {"perl", "-"},
+}
+
+// Interpreter returns the command line used to run snippets written in lang.
+func Interpreter(lang string) ([]string, bool) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		return nil, false
+	}
+	env := "SNIPSTER_RUN_" + strings.ToUpper(strings.NewReplacer("-", "_", "+", "P", "#", "SHARP").Replace(lang))
+	if v := strings.Fields(os.Getenv(env)); len(v) > 0 {
+		return v, true
+	}
+	argv, ok := defaultInterpreters[lang]
+	return argv, ok
+}
+
+// Event is emitted while a snippet runs: one per output line, then a final Done event.
+type Event struct {
+	Line     string
+	Done     bool
+	ExitCode int
+	Duration time.Duration
+	Err      error
+}
+
+// Start runs content through the interpreter for lang and streams interleaved
+// stdout/stderr lines on the returned channel, which is closed after the Done event.
+// Cancelling ctx kills the process.
+func Start(ctx context.Context, lang, content string) (<-chan Event, error) {
+	argv, ok := Interpreter(lang)
+	if !ok {
+		return nil, fmt.Errorf("no interpreter for language %q", lang)
+	}
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.WaitDelay = 2 * time.Second
+	setProcessGroup(cmd)
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Event, 64)
+	go func() {
+		defer close(ch)
+		waitErr := make(chan error, 1)
+		go func() {
+			err := cmd.Wait()
+			pw.Close()
+			waitErr <- err
+		}()
+
+		sc := bufio.NewScanner(pr)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			ch <- Event{Line: sc.Text()}
+		}
+		// Drain anything left if the scanner stopped on an overlong line.
+		_, _ = io.Copy(io.Discard, pr)
+
+		err := <-waitErr
+		ev := Event{Done: true, Duration: time.Since(start), ExitCode: cmd.ProcessState.ExitCode()}
+		var exitErr *exec.ExitError
+		if ctx.Err() != nil {
+			ev.Err = ctx.Err()
+		} else if err != nil && !errors.As(err, &exitErr) {
+			ev.Err = err
+		}
+		ch <- ev
+	}()
+	return ch, nil
+}
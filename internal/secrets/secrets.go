@@ -0,0 +1,202 @@
+package secrets
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Finding is a likely secret inside a snippet's content.
+type Finding struct {
+	Kind  string // e.g. "aws_access_key"
+	Line  int    // 1-based line number
+	Start int    // byte offsets of the value within the line
+	End   int
+	Value string
+	// Where names the text the finding comes from when several are scanned:
+	// empty for the main content, otherwise a file name or "description".
+	Where string
+}
+
+// Masked returns the value with everything but a short prefix hidden.
+func (f Finding) Masked() string {
+	if len(f.Value) <= 4 {
+		return "****"
+	}
+	return f.Value[:4] + strings.Repeat("*", min(len(f.Value)-4, 12))
+}
+
+type rule struct {
+	kind  string
+	re    *regexp.Regexp
+	group int // submatch holding the secret value (0 = whole match)
+}
+
+var rules = []rule{
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`), 0},
+	{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), 0},
+	{"aws_secret_key", regexp.MustCompile(`(?i)aws_secret_access_key\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`), 1},
+	{"github_token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`), 0},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]+`), 0},
+	{"password", regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key)\s*[=:]\s*["']?([^\s"'&;,]+)`), 1},
+}
+
+var (
+	tokenRe  = regexp.MustCompile(`[A-Za-z0-9+/_-]{24,}={0,2}`)
+	keyEndRe = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// Minimum Shannon entropy (bits per char) for a long token to look random.
+const entropyThreshold = 4.2
+
+// Scan looks for common secret patterns line by line. Values that are already
+// snipster placeholders ({{name}}) are ignored.
+func Scan(content string) []Finding {
+	var out []Finding
+	inKey := false
+	for i, line := range strings.Split(content, "\n") {
+		// The body of a private key block is reported once, on its BEGIN line.
+		if inKey {
+			inKey = !keyEndRe.MatchString(line)
+			continue
+		}
+		var taken [][2]int
+		overlaps := func(a, b int) bool {
+			for _, t := range taken {
+				if a < t[1] && b > t[0] {
+					return true
+				}
+			}
+			return false
+		}
+		for _, r := range rules {
+			for _, m := range r.re.FindAllStringSubmatchIndex(line, -1) {
+				a, b := m[2*r.group], m[2*r.group+1]
+				if a < 0 || overlaps(a, b) {
+					continue
+				}
+				v := line[a:b]
+				if strings.HasPrefix(v, "{{") || strings.HasPrefix(v, "$") {
+					continue
+				}
+				taken = append(taken, [2]int{a, b})
+				out = append(out, Finding{Kind: r.kind, Line: i + 1, Start: a, End: b, Value: v})
+				if r.kind == "private_key" && !keyEndRe.MatchString(line[b:]) {
+					inKey = true
+				}
+			}
+		}
+		for _, m := range tokenRe.FindAllStringIndex(line, -1) {
+			if overlaps(m[0], m[1]) {
+				continue
+			}
+			v := line[m[0]:m[1]]
+			if entropy(v) < entropyThreshold || !mixedClasses(v) {
+				continue
+			}
+			out = append(out, Finding{Kind: "high_entropy", Line: i + 1, Start: m[0], End: m[1], Value: v})
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool {
+		if out[a].Line != out[b].Line {
+			return out[a].Line < out[b].Line
+		}
+		return out[a].Start < out[b].Start
+	})
+	return out
+}
+
+// Redact replaces each finding with a placeholder named after its kind, so the
+// value can be provided when the snippet is used. Private key blocks are
+// replaced as a whole.
+func Redact(content string, findings []Finding) string {
+	lines := strings.Split(content, "\n")
+	var dropped []int
+	// Replace from the end of each line so earlier offsets stay valid.
+	for i := len(findings) - 1; i >= 0; i-- {
+		f := findings[i]
+		if f.Line < 1 || f.Line > len(lines) {
+			continue
+		}
+		ln := lines[f.Line-1]
+		if f.End > len(ln) || ln[f.Start:f.End] != f.Value {
+			continue
+		}
+		lines[f.Line-1] = ln[:f.Start] + "{{" + f.Kind + "}}" + ln[f.End:]
+		if f.Kind == "private_key" && !keyEndRe.MatchString(ln[f.End:]) {
+			for j := f.Line; j < len(lines); j++ {
+				dropped = append(dropped, j)
+				if keyEndRe.MatchString(lines[j]) {
+					break
+				}
+			}
+		}
+	}
+	if len(dropped) == 0 {
+		return strings.Join(lines, "\n")
+	}
+	skip := make(map[int]bool, len(dropped))
+	for _, j := range dropped {
+		skip[j] = true
+	}
+	kept := lines[:0]
+	for j, ln := range lines {
+		if !skip[j] {
+			kept = append(kept, ln)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// Mode controls what happens when secrets are found before saving.
+type Mode string
+
+const (
+	ModeWarn  Mode = "warn"
+	ModeBlock Mode = "block"
+	ModeOff   Mode = "off"
+)
+
+// ModeFromEnv reads SNIPSTER_SECRETS (warn, block or off). Defaults to warn.
+func ModeFromEnv() Mode {
+	switch Mode(strings.ToLower(strings.TrimSpace(os.Getenv("SNIPSTER_SECRETS")))) {
+	case ModeBlock:
+		return ModeBlock
+	case ModeOff:
+		return ModeOff
+	default:
+		return ModeWarn
+	}
+}
+
+func entropy(s string) float64 {
+	freq := map[rune]float64{}
+	for _, r := range s {
+		freq[r]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, c := range freq {
+		p := c / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// mixedClasses filters out long identifiers and paths: random tokens mix digits and letters.
+func mixedClasses(s string) bool {
+	var lower, upper, digit bool
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	return digit && (lower || upper)
+}
@@ -0,0 +1,397 @@
+// Package server exposes a snippet library over a local HTTP/JSON API, for
+// editor plugins and scripts that should not shell out to snip.
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// Event is one change of the library, sent on the /events stream.
+type Event struct {
+	Type string `json:"type"` // created, updated or deleted
+	ID   string `json:"id"`
+	ETag string `json:"etag,omitempty"`
+}
+
+// How often an idle /events stream sends a keep-alive comment.
+const keepAlive = 30 * time.Second
+
+// Server serves the snippets of a Repo. Writes go through the server, but the
+// library may also change on disk (TUI, git pull…): Watch picks those changes up.
+type Server struct {
+	repo  *snippets.Repo
+	token string
+
+	// mu serializes writes, so that an If-Match check and the write it guards
+	// see the same file, and protects etags.
+	mu    sync.Mutex
+	etags map[string]string // ETag per snippet ID at the last sync
+
+	subsMu sync.Mutex
+	subs   map[chan Event]struct{}
+}
+
+// New returns a server for repo. Requests must carry token as a bearer token,
+// unless token is empty.
+func New(repo *snippets.Repo, token string) *Server {
+	s := &Server{repo: repo, token: token, subs: map[chan Event]struct{}{}}
+	s.mu.Lock()
+	s.sync()
+	s.mu.Unlock()
+	return s
+}
+
+// Handler returns the HTTP handler of the API:
+//
+//	GET    /snippets?q=&category=&tag=   list or search
+//	POST   /snippets                     create
+//	GET    /snippets/{id}                get (ETag, If-None-Match)
+//	PUT    /snippets/{id}                update (If-Match required)
+//	DELETE /snippets/{id}                delete (If-Match honoured)
+//	GET    /events                       server-sent events of changes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /snippets", s.list)
+	mux.HandleFunc("POST /snippets", s.create)
+	mux.HandleFunc("GET /snippets/{id}", s.get)
+	mux.HandleFunc("PUT /snippets/{id}", s.update)
+	mux.HandleFunc("DELETE /snippets/{id}", s.remove)
+	mux.HandleFunc("GET /events", s.events)
+	return s.auth(mux)
+}
+
+// Watch reloads the library every interval until ctx is done, and publishes
+// the changes made outside the server.
+func (s *Server) Watch(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.mu.Lock()
+			s.sync()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// auth checks the bearer token. EventSource clients cannot set headers, so the
+// token is also accepted as a "token" query parameter.
+func (s *Server) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				got = r.URL.Query().Get("token")
+			}
+			if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, http.StatusUnauthorized, "missing or invalid token")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	all, err := s.repo.LoadAll()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	q := r.URL.Query()
+	cat := strings.Trim(q.Get("category"), "/")
+	tag := q.Get("tag")
+	out := []snippets.Snippet{}
+	for _, sn := range snippets.Search(all, q.Get("q")) {
+		if cat != "" && sn.Category != cat && !strings.HasPrefix(sn.Category, cat+"/") {
+			continue
+		}
+		if tag != "" && !snippets.HasTag(sn.Tags, tag) {
+			continue
+		}
+		out = append(out, sn)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	sn, ok := s.find(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	tag := ETag(sn)
+	w.Header().Set("ETag", tag)
+	if r.Header.Get("If-None-Match") == tag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, sn)
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	in, ok := decode(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if in.ID != "" {
+		all, _ := s.repo.LoadAll()
+		if _, taken := snippets.Find(all, in.ID); taken {
+			writeError(w, http.StatusConflict, fmt.Sprintf("snippet %s already exists", in.ID))
+			return
+		}
+	}
+	in.CreatedAt, in.Path = time.Time{}, ""
+	sn, err := s.repo.Create(in)
+	if errors.Is(err, snippets.ErrAliasTaken) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sn = s.reread(sn)
+	w.Header().Set("Location", "/snippets/"+sn.ID)
+	w.Header().Set("ETag", ETag(sn))
+	writeJSON(w, http.StatusCreated, sn)
+}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request) {
+	in, ok := decode(w, r)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.find(w, r.PathValue("id"))
+	if !ok || !precondition(w, r, cur, true) {
+		return
+	}
+	// The file, identity and creation date are the server's.
+	in.ID, in.Path, in.CreatedAt = cur.ID, cur.Path, cur.CreatedAt
+	in.SchemaVersion, in.Extra = cur.SchemaVersion, cur.Extra
+	var sn snippets.Snippet
+	var err error
+	if in.Category != cur.Category {
+		sn, err = s.repo.Move(in, in.Category)
+	} else {
+		sn, err = s.repo.Update(in)
+	}
+	if errors.Is(err, snippets.ErrAliasTaken) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sn = s.reread(sn)
+	w.Header().Set("ETag", ETag(sn))
+	writeJSON(w, http.StatusOK, sn)
+}
+
+func (s *Server) remove(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.find(w, r.PathValue("id"))
+	if !ok || !precondition(w, r, cur, false) {
+		return
+	}
+	if err := s.repo.Delete(cur); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sync()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	ch := s.subscribe()
+	defer s.unsubscribe(ch)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	t := time.NewTicker(keepAlive)
+	defer t.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-t.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case ev := <-ch:
+			b, _ := json.Marshal(ev)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, b)
+		}
+		flusher.Flush()
+	}
+}
+
+// find loads the snippet with the given ID or slug, answering 404 itself.
+func (s *Server) find(w http.ResponseWriter, ref string) (snippets.Snippet, bool) {
+	all, err := s.repo.LoadAll()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return snippets.Snippet{}, false
+	}
+	sn, ok := snippets.Find(all, ref)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no snippet %q", ref))
+	}
+	return sn, ok
+}
+
+// precondition checks If-Match against the current version of the snippet, so
+// that a client cannot overwrite a change it has not seen. With required, a
+// missing If-Match is refused ("*" matches any version).
+func precondition(w http.ResponseWriter, r *http.Request, cur snippets.Snippet, required bool) bool {
+	match := r.Header.Get("If-Match")
+	switch {
+	case match == "" && required:
+		writeError(w, http.StatusPreconditionRequired, "If-Match header required")
+		return false
+	case match == "" || match == "*" || match == ETag(cur):
+		return true
+	}
+	w.Header().Set("ETag", ETag(cur))
+	writeError(w, http.StatusPreconditionFailed, "snippet changed since it was read")
+	return false
+}
+
+// reread syncs after a write and returns sn as read back from disk, so that the
+// ETag answered is the one the next GET computes. Callers hold s.mu.
+func (s *Server) reread(sn snippets.Snippet) snippets.Snippet {
+	for _, o := range s.sync() {
+		if o.ID == sn.ID {
+			return o
+		}
+	}
+	return sn
+}
+
+// sync reloads the library, publishes what changed since the last sync and
+// returns the snippets. Callers hold s.mu.
+func (s *Server) sync() []snippets.Snippet {
+	all, err := s.repo.LoadAll()
+	if err != nil {
+		return nil
+	}
+	etags := make(map[string]string, len(all))
+	for _, sn := range all {
+		etags[sn.ID] = ETag(sn)
+	}
+	if s.etags != nil {
+		for id, tag := range etags {
+			if old, ok := s.etags[id]; !ok {
+				s.publish(Event{Type: "created", ID: id, ETag: tag})
+			} else if old != tag {
+				s.publish(Event{Type: "updated", ID: id, ETag: tag})
+			}
+		}
+		for id := range s.etags {
+			if _, ok := etags[id]; !ok {
+				s.publish(Event{Type: "deleted", ID: id})
+			}
+		}
+	}
+	s.etags = etags
+	return all
+}
+
+func (s *Server) subscribe() chan Event {
+	ch := make(chan Event, 16)
+	s.subsMu.Lock()
+	s.subs[ch] = struct{}{}
+	s.subsMu.Unlock()
+	return ch
+}
+
+func (s *Server) unsubscribe(ch chan Event) {
+	s.subsMu.Lock()
+	delete(s.subs, ch)
+	s.subsMu.Unlock()
+}
+
+// publish sends ev to every stream; a stream too slow to keep up misses it
+// rather than blocking writes.
+func (s *Server) publish(ev Event) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	for ch := range s.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// ETag returns the entity tag of a snippet: a hash of its serialized fields.
+func ETag(s snippets.Snippet) string {
+	b, _ := json.Marshal(s)
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// decode reads a snippet from the request body and checks the fields needed to
+// file it.
+func decode(w http.ResponseWriter, r *http.Request) (snippets.Snippet, bool) {
+	var s snippets.Snippet
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&s); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return s, false
+	}
+	s.Title = strings.TrimSpace(s.Title)
+	s.Category = strings.Trim(strings.TrimSpace(s.Category), "/")
+	if err := validate(s); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return s, false
+	}
+	return s, true
+}
+
+func validate(s snippets.Snippet) error {
+	if s.Title == "" {
+		return errors.New("title is required")
+	}
+	if s.Category == "" {
+		return errors.New("category is required")
+	}
+	// The category is a folder under the library: keep it there.
+	return snippets.ValidateCategory(s.Category)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
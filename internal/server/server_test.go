@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+const testToken = "secret"
+
+// newTestServer serves an empty library stored as Markdown, the format where a
+// lossy round trip would show up as a changed ETag.
+func newTestServer(t *testing.T) (*httptest.Server, *snippets.Repo) {
+	t.Helper()
+	repo := snippets.NewRepo(t.TempDir())
+	repo.SetFormat(snippets.FormatMarkdown)
+	ts := httptest.NewServer(New(repo, testToken).Handler())
+	t.Cleanup(ts.Close)
+	return ts, repo
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, body string, header map[string]string) *http.Response {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, ts.URL+path, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decodeBody[T any](t *testing.T, resp *http.Response) T {
+	t.Helper()
+	var v T
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func wantStatus(t *testing.T, resp *http.Response, code int) {
+	t.Helper()
+	if resp.StatusCode != code {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("%s %s: status %d, want %d: %s", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, code, b)
+	}
+}
+
+func create(t *testing.T, ts *httptest.Server, body string) (snippets.Snippet, string) {
+	t.Helper()
+	resp := do(t, ts, "POST", "/snippets", body, nil)
+	wantStatus(t, resp, http.StatusCreated)
+	return decodeBody[snippets.Snippet](t, resp), resp.Header.Get("ETag")
+}
+
+func TestAuth(t *testing.T) {
+	ts, _ := newTestServer(t)
+	for name, header := range map[string]string{"missing": "", "wrong": "Bearer nope"} {
+		req, _ := http.NewRequest("GET", ts.URL+"/snippets", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s token: status %d, want 401", name, resp.StatusCode)
+		}
+	}
+	resp, err := ts.Client().Get(ts.URL + "/snippets?token=" + testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("query token: status %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestListSearchGet(t *testing.T) {
+	ts, _ := newTestServer(t)
+	ps, _ := create(t, ts, `{"title":"docker ps","category":"docker","tags":["ps"],"content":"docker ps -a\n"}`)
+	create(t, ts, `{"title":"ssh tunnel","category":"net/ssh","content":"ssh -L 8080:localhost:80 host\n"}`)
+
+	all := decodeBody[[]snippets.Snippet](t, do(t, ts, "GET", "/snippets", "", nil))
+	if len(all) != 2 {
+		t.Fatalf("list: %d snippets, want 2", len(all))
+	}
+	for query, want := range map[string]string{"?q=tunnel": "ssh tunnel", "?category=docker": "docker ps", "?tag=ps": "docker ps", "?category=net": "ssh tunnel"} {
+		got := decodeBody[[]snippets.Snippet](t, do(t, ts, "GET", "/snippets"+query, "", nil))
+		if len(got) != 1 || got[0].Title != want {
+			t.Errorf("%s: got %v, want only %q", query, got, want)
+		}
+	}
+
+	resp := do(t, ts, "GET", "/snippets/"+ps.ID, "", nil)
+	wantStatus(t, resp, http.StatusOK)
+	if got := decodeBody[snippets.Snippet](t, resp); got.Content != "docker ps -a\n" {
+		t.Errorf("get: content %q", got.Content)
+	}
+	resp = do(t, ts, "GET", "/snippets/"+ps.ID, "", map[string]string{"If-None-Match": resp.Header.Get("ETag")})
+	wantStatus(t, resp, http.StatusNotModified)
+	wantStatus(t, do(t, ts, "GET", "/snippets/nope", "", nil), http.StatusNotFound)
+}
+
+func TestCreateUpdateDelete(t *testing.T) {
+	ts, repo := newTestServer(t)
+	resp := do(t, ts, "POST", "/snippets", `{"title":"list files","category":"sys","content":"ls -la\n"}`, nil)
+	wantStatus(t, resp, http.StatusCreated)
+	sn := decodeBody[snippets.Snippet](t, resp)
+	if loc := resp.Header.Get("Location"); loc != "/snippets/"+sn.ID {
+		t.Errorf("Location %q", loc)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("create: no ETag")
+	}
+	// The ETag of a write is the one the next read computes.
+	if got := do(t, ts, "GET", "/snippets/"+sn.ID, "", nil).Header.Get("ETag"); got != etag {
+		t.Fatalf("GET ETag %s, create answered %s", got, etag)
+	}
+
+	body := `{"title":"list all files","category":"sys","content":"ls -la\n"}`
+	wantStatus(t, do(t, ts, "PUT", "/snippets/"+sn.ID, body, nil), http.StatusPreconditionRequired)
+	wantStatus(t, do(t, ts, "PUT", "/snippets/"+sn.ID, body, map[string]string{"If-Match": `"stale"`}), http.StatusPreconditionFailed)
+	resp = do(t, ts, "PUT", "/snippets/"+sn.ID, body, map[string]string{"If-Match": etag})
+	wantStatus(t, resp, http.StatusOK)
+	updated := resp.Header.Get("ETag")
+	if updated == etag {
+		t.Error("update kept the ETag")
+	}
+	if got := do(t, ts, "GET", "/snippets/"+sn.ID, "", nil).Header.Get("ETag"); got != updated {
+		t.Fatalf("GET ETag %s, update answered %s", got, updated)
+	}
+	// The old version is gone: its ETag no longer matches.
+	wantStatus(t, do(t, ts, "DELETE", "/snippets/"+sn.ID, "", map[string]string{"If-Match": etag}), http.StatusPreconditionFailed)
+
+	wantStatus(t, do(t, ts, "DELETE", "/snippets/"+sn.ID, "", map[string]string{"If-Match": updated}), http.StatusNoContent)
+	wantStatus(t, do(t, ts, "GET", "/snippets/"+sn.ID, "", nil), http.StatusNotFound)
+	if all, _ := repo.LoadAll(); len(all) != 0 {
+		t.Errorf("library still holds %d snippets", len(all))
+	}
+}
+
+func TestEvents(t *testing.T) {
+	ts, _ := newTestServer(t)
+	req, _ := http.NewRequest("GET", ts.URL+"/events?token="+testToken, nil)
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// The server subscribes before sending its ": connected" comment.
+	br := bufio.NewReader(resp.Body)
+	if line, err := br.ReadString('\n'); err != nil || !strings.HasPrefix(line, ":") {
+		t.Fatalf("stream start %q: %v", line, err)
+	}
+	events := make(chan Event)
+	go func() {
+		sc := bufio.NewScanner(br)
+		for sc.Scan() {
+			if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+				var ev Event
+				if json.Unmarshal([]byte(data), &ev) == nil {
+					events <- ev
+				}
+			}
+		}
+		close(events)
+	}()
+
+	sn, etag := create(t, ts, `{"title":"uptime","category":"sys","content":"uptime\n"}`)
+	wantStatus(t, do(t, ts, "PUT", "/snippets/"+sn.ID, `{"title":"uptime","category":"sys","content":"uptime -p\n"}`, map[string]string{"If-Match": etag}), http.StatusOK)
+	wantStatus(t, do(t, ts, "DELETE", "/snippets/"+sn.ID, "", nil), http.StatusNoContent)
+
+	for _, want := range []string{"created", "updated", "deleted"} {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatalf("stream closed, want %s", want)
+			}
+			if ev.Type != want || ev.ID != sn.ID {
+				t.Errorf("event %s %s, want %s %s", ev.Type, ev.ID, want, sn.ID)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s event", want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Copy buttons and client-side search over search.json.
+(function () {
+  const root = document.body.dataset.root;
+
+  document.querySelectorAll("button.copy").forEach((btn) => {
+    btn.addEventListener("click", () => {
+      const code = document.getElementById(btn.dataset.target).innerText;
+      navigator.clipboard.writeText(code).then(() => {
+        btn.textContent = "Copied";
+        setTimeout(() => (btn.textContent = "Copy"), 1500);
+      });
+    });
+  });
+
+  const input = document.getElementById("search");
+  const results = document.getElementById("results");
+  let index = null;
+  const esc = (s) => s.replace(/[&<>"]/g, (c) => ({ "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;" })[c]);
+
+  input.addEventListener("input", async () => {
+    const q = input.value.trim().toLowerCase();
+    if (!q) {
+      results.innerHTML = "";
+      return;
+    }
+    if (!index) {
+      index = await fetch(root + "search.json").then((r) => r.json());
+    }
+    const tag = q.startsWith("#") ? q.slice(1) : null;
+    const hits = index.filter((e) =>
+      tag !== null
+        ? e.tags.some((t) => t.toLowerCase() === tag || t.toLowerCase().startsWith(tag + "/"))
+        : [e.title, e.category, e.text, e.tags.join(" ")].some((f) => f.toLowerCase().includes(q))
+    );
+    results.innerHTML =
+      "<h2>" + hits.length + " result(s)</h2><ul class=\"snippets\">" +
+      hits.map((e) => '<li><a href="' + root + e.url + '">' + esc(e.title) + '</a> <span class="muted">' + esc(e.category) + "</span></li>").join("") +
+      "</ul>";
+  });
+})();
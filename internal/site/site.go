@@ -0,0 +1,466 @@
+// Package site renders a snippet library as a static HTML site: category and
+// tag pages, highlighted code with copy buttons, and a client-side search.
+package site
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+	"github.com/HrodWolfS/snipster/internal/ui"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// Characters of content kept per snippet in the search index.
+const indexText = 2000
+
+// Result summarizes a Build.
+type Result struct {
+	Snippets int
+	// Encrypted snippets are never published.
+	Skipped int
+	// Snippets whose category would put their page outside the site.
+	Invalid int
+}
+
+// category is a folder of the library and the snippets filed directly in it.
+type category struct {
+	Path     string
+	Name     string
+	Children []*category
+	Snippets []snippets.Snippet
+	Total    int // snippets in the folder and its subfolders
+}
+
+// Build writes the site for all into dir.
+func Build(all []snippets.Snippet, dir string) (Result, error) {
+	var res Result
+	var pub []snippets.Snippet
+	for _, s := range all {
+		if s.Encrypted {
+			res.Skipped++
+			continue
+		}
+		if snippets.ValidateCategory(strings.Trim(s.Category, "/")) != nil {
+			res.Invalid++
+			continue
+		}
+		pub = append(pub, s)
+	}
+	sort.Slice(pub, func(i, j int) bool {
+		return strings.ToLower(pub[i].Title) < strings.ToLower(pub[j].Title)
+	})
+	b := &builder{dir: dir, root: tree(pub), tags: snippets.TagIndex(pub), all: pub}
+
+	if err := b.copyAssets(); err != nil {
+		return res, err
+	}
+	if err := b.page("index.html", "Snippets", b.render(indexTmpl, map[string]any{"Root": b.root, "Tags": b.tags})); err != nil {
+		return res, err
+	}
+	var walk func(c *category) error
+	walk = func(c *category) error {
+		if c.Path != "" {
+			data := map[string]any{"Cat": c, "Crumbs": crumbs(c.Path)}
+			if err := b.page(catPage(c.Path), c.Path, b.render(categoryTmpl, data)); err != nil {
+				return err
+			}
+		}
+		for _, s := range c.Snippets {
+			related, _ := snippets.ResolveLinks(pub, s)
+			data := map[string]any{"S": s, "Crumbs": crumbs(s.Category), "Related": related, "Backlinks": snippets.Backlinks(pub, s)}
+			if err := b.page(snippetPage(s), s.Title, b.render(snippetTmpl, data)); err != nil {
+				return err
+			}
+			res.Snippets++
+		}
+		for _, child := range c.Children {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(b.root); err != nil {
+		return res, err
+	}
+	for _, tc := range b.tags {
+		var list []snippets.Snippet
+		for _, s := range pub {
+			if snippets.HasTag(s.Tags, tc.Tag) {
+				list = append(list, s)
+			}
+		}
+		if err := b.page(tagPage(tc.Tag), "#"+tc.Tag, b.render(tagTmpl, map[string]any{"Tag": tc.Tag, "Snippets": list})); err != nil {
+			return res, err
+		}
+	}
+	return res, b.searchIndex(pub)
+}
+
+// tree files the snippets into their category folders.
+func tree(all []snippets.Snippet) *category {
+	root := &category{}
+	byPath := map[string]*category{"": root}
+	var get func(p string) *category
+	get = func(p string) *category {
+		if c, ok := byPath[p]; ok {
+			return c
+		}
+		dir := path.Dir(p)
+		if dir == "." {
+			dir = ""
+		}
+		parent := get(dir)
+		c := &category{Path: p, Name: path.Base(p)}
+		parent.Children = append(parent.Children, c)
+		byPath[p] = c
+		return c
+	}
+	for _, s := range all {
+		c := get(strings.Trim(s.Category, "/"))
+		c.Snippets = append(c.Snippets, s)
+	}
+	var finish func(c *category)
+	finish = func(c *category) {
+		sort.Slice(c.Children, func(i, j int) bool { return c.Children[i].Name < c.Children[j].Name })
+		c.Total = len(c.Snippets)
+		for _, ch := range c.Children {
+			finish(ch)
+			c.Total += ch.Total
+		}
+	}
+	finish(root)
+	return root
+}
+
+// Page paths, relative to the site root and always with "/" separators.
+
+func catPage(cat string) string { return cat + "/index.html" }
+
+// snippetPage names the page of s after its file; a snippet slugged "index"
+// would otherwise replace its category page.
+func snippetPage(s snippets.Snippet) string {
+	slug := s.Slug()
+	if slug == "index" {
+		slug = "index-snippet"
+	}
+	if cat := strings.Trim(s.Category, "/"); cat != "" {
+		return cat + "/" + slug + ".html"
+	}
+	return slug + ".html"
+}
+
+// tagPage keeps the tag hierarchy: cloud/aws is tags/cloud/aws.html. Each
+// segment is escaped rather than slugged, so that "c", "c++" and "c#" get pages
+// of their own.
+func tagPage(tag string) string {
+	var parts []string
+	for _, p := range strings.Split(tag, "/") {
+		parts = append(parts, fileSegment(p))
+	}
+	return "tags/" + strings.Join(parts, "/") + ".html"
+}
+
+// fileSegment escapes s for use as a file or folder name: bytes other than
+// ASCII letters, digits, "-", "_", "+" and inner dots become %XX. A leading dot
+// is escaped too, so no segment is hidden, "." or "..".
+func fileSegment(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '+', c == '.' && i > 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	if b.Len() == 0 {
+		return "%"
+	}
+	return b.String()
+}
+
+type crumb struct{ Name, Path string }
+
+func crumbs(cat string) []crumb {
+	var out []crumb
+	parts := strings.Split(strings.Trim(cat, "/"), "/")
+	for i := range parts {
+		out = append(out, crumb{Name: parts[i], Path: strings.Join(parts[:i+1], "/")})
+	}
+	return out
+}
+
+type builder struct {
+	dir  string
+	root *category
+	tags []snippets.TagCount
+	all  []snippets.Snippet
+	// rel is the path from the page being rendered to the site root.
+	rel string
+}
+
+// href links to a page given by its path from the site root.
+func (b *builder) href(p string) string {
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		segs[i] = url.PathEscape(s)
+	}
+	return b.rel + strings.Join(segs, "/")
+}
+
+func (b *builder) funcs() template.FuncMap {
+	return template.FuncMap{
+		"href":        func(p string) template.URL { return template.URL(b.href(p)) },
+		"catPage":     catPage,
+		"snippetPage": snippetPage,
+		"tagPage":     tagPage,
+		"code":        highlight,
+		"markdown":    b.markdown,
+		"parts":       func(s snippets.Snippet) []snippets.File { return s.Parts() },
+		"indent":      func(depth int) template.CSS { return template.CSS(fmt.Sprintf("margin-left: %dem", depth)) },
+	}
+}
+
+// render executes a page body template; the page path must be set first, as
+// links are relative to it.
+func (b *builder) render(body string, data any) func() (template.HTML, error) {
+	return func() (template.HTML, error) {
+		t, err := template.New("body").Funcs(b.funcs()).Parse(body + navTmpl)
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		if err := t.Execute(&sb, data); err != nil {
+			return "", err
+		}
+		return template.HTML(sb.String()), nil
+	}
+}
+
+// page writes the page at p (relative to the site root) in the common layout.
+func (b *builder) page(p, title string, body func() (template.HTML, error)) error {
+	b.rel = strings.Repeat("../", strings.Count(p, "/"))
+	main, err := body()
+	if err != nil {
+		return err
+	}
+	t, err := template.New("layout").Funcs(b.funcs()).Parse(layoutTmpl + navTmpl)
+	if err != nil {
+		return err
+	}
+	out := filepath.Join(b.dir, filepath.FromSlash(p))
+	if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return t.Execute(f, map[string]any{"Title": title, "Rel": b.rel, "Root": b.root, "Main": main})
+}
+
+func (b *builder) copyAssets() error {
+	for _, name := range []string{"style.css", "app.js"} {
+		data, err := assets.ReadFile("assets/" + name)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(b.dir, 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(b.dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchEntry is one snippet of search.json.
+type searchEntry struct {
+	Title    string   `json:"title"`
+	Category string   `json:"category"`
+	Language string   `json:"language"`
+	Tags     []string `json:"tags"`
+	URL      string   `json:"url"`
+	Text     string   `json:"text"`
+}
+
+func (b *builder) searchIndex(all []snippets.Snippet) error {
+	b.rel = ""
+	entries := make([]searchEntry, 0, len(all))
+	for _, s := range all {
+		text := snippets.JoinParts(s)
+		if s.Description != "" {
+			text = s.Description + "\n" + text
+		}
+		if r := []rune(text); len(r) > indexText {
+			text = string(r[:indexText])
+		}
+		tags := s.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		entries = append(entries, searchEntry{
+			Title: s.Title, Category: s.Category, Language: s.Language,
+			Tags: tags, URL: b.href(snippetPage(s)), Text: text,
+		})
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(b.dir, "search.json"), data, 0o644)
+}
+
+// highlight renders code with the keyword classification of the TUI preview.
+func highlight(content, lang string) template.HTML {
+	var sb strings.Builder
+	for i, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		for _, t := range ui.Tokenize(line, lang) {
+			text := template.HTMLEscapeString(t.Text)
+			if t.Kind == ui.TokenKeyword {
+				sb.WriteString(`<span class="kw">` + text + `</span>`)
+			} else {
+				sb.WriteString(text)
+			}
+		}
+	}
+	return template.HTML(sb.String())
+}
+
+// markdown renders a description with the Markdown subset of the TUI preview;
+// [[ref]] links point to the page of the snippet, when it is published.
+func (b *builder) markdown(text string) template.HTML {
+	var sb strings.Builder
+	list := false
+	for _, ml := range ui.ParseMarkdown(text) {
+		if list && ml.Bullet == "" {
+			sb.WriteString("</ul>\n")
+			list = false
+		}
+		switch {
+		case len(ml.Spans) == 0:
+			continue
+		case ml.Bullet != "":
+			if !list {
+				sb.WriteString("<ul>\n")
+				list = true
+			}
+			sb.WriteString("<li>")
+		default:
+			sb.WriteString("<p>")
+		}
+		for _, sp := range ml.Spans {
+			text := template.HTMLEscapeString(sp.Text)
+			switch sp.Kind {
+			case ui.SpanEmphasis:
+				sb.WriteString("<em>" + text + "</em>")
+			case ui.SpanStrong:
+				sb.WriteString("<strong>" + text + "</strong>")
+			case ui.SpanCode:
+				sb.WriteString("<code>" + text + "</code>")
+			case ui.SpanRef:
+				if t, ok := snippets.Find(b.all, sp.Text); ok {
+					sb.WriteString(`<a href="` + template.HTMLEscapeString(b.href(snippetPage(t))) + `">` + template.HTMLEscapeString(t.Title) + "</a>")
+				} else {
+					sb.WriteString(text)
+				}
+			case ui.SpanLink:
+				sb.WriteString(`<a href="` + template.HTMLEscapeString(safeURL(sp.URL)) + `">` + text + "</a>")
+			default:
+				sb.WriteString(text)
+			}
+		}
+		if ml.Bullet != "" {
+			sb.WriteString("</li>\n")
+		} else {
+			sb.WriteString("</p>\n")
+		}
+	}
+	if list {
+		sb.WriteString("</ul>\n")
+	}
+	return template.HTML(sb.String())
+}
+
+// safeURL keeps web and relative links; anything else (javascript:…) becomes "#".
+func safeURL(u string) string {
+	if scheme, _, ok := strings.Cut(u, ":"); ok && !strings.ContainsAny(scheme, "/?#") {
+		switch strings.ToLower(scheme) {
+		case "http", "https", "mailto":
+		default:
+			return "#"
+		}
+	}
+	return u
+}
+
+const layoutTmpl = `<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} · snipster</title>
+<link rel="stylesheet" href="{{.Rel}}style.css">
+</head>
+<body data-root="{{.Rel}}">
+<nav>
+<h1><a href="{{href "index.html"}}">snipster</a></h1>
+<input id="search" type="search" placeholder="Search, or #tag" autocomplete="off">
+{{template "tree" .Root}}
+</nav>
+<main>
+<div id="results"></div>
+<div id="content">{{.Main}}</div>
+</main>
+<script src="{{.Rel}}app.js"></script>
+</body>
+</html>
+`
+
+const navTmpl = `{{define "tree"}}{{if .Children}}<ul>{{range .Children}}<li><a href="{{href (catPage .Path)}}">{{.Name}}/</a> <span class="count">{{.Total}}</span>{{template "tree" .}}</li>{{end}}</ul>{{end}}{{end}}
+{{define "list"}}<ul class="snippets">{{range .}}<li><a href="{{href (snippetPage .)}}">{{.Title}}</a> <span class="muted">{{.Category}}{{with .Language}} · {{.}}{{end}}</span></li>{{end}}</ul>{{end}}
+{{define "crumbs"}}<div class="crumbs"><a href="{{href "index.html"}}">snippets</a>{{range .}} / <a href="{{href (catPage .Path)}}">{{.Name}}</a>{{end}}</div>{{end}}`
+
+const indexTmpl = `<h2>Categories</h2>
+{{template "tree" .Root}}
+{{if .Tags}}<h2>Tags</h2>
+<div>{{range .Tags}}<div style="{{indent .Depth}}"><a href="{{href (tagPage .Tag)}}">#{{.Tag}}</a> <span class="count">{{.Count}}</span></div>{{end}}</div>{{end}}`
+
+const categoryTmpl = `{{template "crumbs" .Crumbs}}
+<h2>{{.Cat.Path}}</h2>
+{{if .Cat.Children}}<h3>Folders</h3>{{template "tree" .Cat}}{{end}}
+{{if .Cat.Snippets}}<h3>Snippets</h3>{{template "list" .Cat.Snippets}}{{end}}`
+
+const snippetTmpl = `{{template "crumbs" .Crumbs}}
+<h2>{{.S.Title}}</h2>
+<p class="muted">{{.S.Language}}{{if .S.Tags}} · <span class="tags">{{range .S.Tags}}<a href="{{href (tagPage .)}}">#{{.}}</a>{{end}}</span>{{end}}</p>
+{{with .S.SourceURL}}<p class="muted">Source: <a href="{{.}}">{{.}}</a>{{with $.S.Author}} · by {{.}}{{end}}{{with $.S.License}} · {{.}}{{end}}</p>{{end}}
+{{with .S.Description}}<div class="description">{{markdown .}}</div>{{end}}
+{{$lang := .S.Language}}{{range $i, $p := parts .S}}<div class="file">
+<div class="file-head"><span>{{if ne $p.Name "main"}}{{$p.Name}}{{end}}</span><button class="copy" data-target="code-{{$i}}">Copy</button></div>
+<pre><code id="code-{{$i}}">{{code $p.Content (or $p.Language $lang)}}</code></pre>
+</div>{{end}}
+{{if .Related}}<h3>Related</h3>{{template "list" .Related}}{{end}}
+{{if .Backlinks}}<h3>Linked from</h3>{{template "list" .Backlinks}}{{end}}`
+
+const tagTmpl = `<h2>#{{.Tag}}</h2>
+{{template "list" .Snippets}}`
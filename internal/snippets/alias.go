@@ -0,0 +1,90 @@
+package snippets
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrAliasTaken is returned when saving a snippet with an alias, or an invalid
+// one, that could not name it alone.
+var ErrAliasTaken = errors.New("alias taken")
+
+// NormalizeAliases trims and lowercases aliases and drops empty and repeated ones.
+func NormalizeAliases(aliases []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, a := range aliases {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a != "" && !seen[a] {
+			seen[a] = true
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// HasAlias reports whether ref is one of the aliases of s, ignoring case.
+func HasAlias(s Snippet, ref string) bool {
+	ref = strings.TrimSpace(ref)
+	for _, a := range s.Aliases {
+		if strings.EqualFold(a, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckAliases verifies that the aliases of s are valid short names (no
+// spaces, commas or "#") used by no other snippet of all, as an alias or an ID.
+func CheckAliases(all []Snippet, s Snippet) error {
+	for _, a := range s.Aliases {
+		if a == "" || strings.ContainsAny(a, " \t\n,#") {
+			return fmt.Errorf("%w: invalid alias %q", ErrAliasTaken, a)
+		}
+		for _, o := range all {
+			if o.ID == s.ID {
+				continue
+			}
+			if HasAlias(o, a) || strings.EqualFold(o.ID, a) {
+				return fmt.Errorf("%w: %q is already used by %s", ErrAliasTaken, a, o.Title)
+			}
+		}
+	}
+	return nil
+}
+
+// checkAliases validates the aliases of s before a write, against all or, when
+// nil, against the files of the library. Files that cannot be read are skipped:
+// snip doctor reports them, and they should not make every save with an alias
+// fail.
+func (r *Repo) checkAliases(all []Snippet, s Snippet) error {
+	if len(s.Aliases) == 0 || all != nil {
+		return CheckAliases(all, s)
+	}
+	err := filepath.WalkDir(r.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != r.root {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return skipHidden(path, r.root, d)
+		}
+		if _, ok := formatOf(d.Name()); !ok || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		if o, _, err := loadFile(path); err == nil {
+			all = append(all, o)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return CheckAliases(all, s)
+}
@@ -0,0 +1,108 @@
+package snippets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Common file extensions per language, used to name the main content when materializing.
+var langExt = map[string]string{
+	"bash": ".sh", "sh": ".sh", "zsh": ".zsh", "fish": ".fish",
+	"go": ".go", "golang": ".go",
+	"js": ".js", "javascript": ".js", "ts": ".ts", "typescript": ".ts",
+	"python": ".py", "py": ".py", "ruby": ".rb", "sql": ".sql",
+	"yaml": ".yml", "yml": ".yml", "json": ".json", "toml": ".toml",
+}
+
+// Languages of the common file extensions, the inverse of langExt.
+var extLang = map[string]string{
+	".sh": "bash", ".bash": "bash", ".zsh": "zsh", ".fish": "fish", ".go": "go",
+	".js": "javascript", ".mjs": "javascript", ".ts": "typescript", ".py": "python",
+	".rb": "ruby", ".sql": "sql", ".yml": "yaml", ".yaml": "yaml", ".json": "json", ".toml": "toml",
+}
+
+// LanguageFor guesses the language of a file from its name, or returns "".
+func LanguageFor(name string) string {
+	switch strings.ToLower(filepath.Base(name)) {
+	case "dockerfile":
+		return "dockerfile"
+	case "makefile":
+		return "makefile"
+	}
+	return extLang[strings.ToLower(filepath.Ext(name))]
+}
+
+// FileNameFor returns the file name used for the main content of s.
+func FileNameFor(s Snippet) string {
+	switch strings.ToLower(s.Language) {
+	case "dockerfile":
+		return "Dockerfile"
+	case "makefile":
+		return "Makefile"
+	}
+	ext, ok := langExt[strings.ToLower(s.Language)]
+	if !ok {
+		ext = ".txt"
+	}
+	return s.Slug() + ext
+}
+
+// Materialize writes every part of s into dir and returns the written paths.
+// Existing files are only replaced when overwrite is set.
+func Materialize(s Snippet, dir string, overwrite bool) ([]string, error) {
+	parts := s.Parts()
+	if s.Content != "" || len(s.Files) == 0 {
+		parts[0].Name = FileNameFor(s)
+	}
+	// Check every target first so that a bad name does not leave a half-written bundle.
+	targets := make([]string, len(parts))
+	for i, p := range parts {
+		rel := filepath.Clean(filepath.FromSlash(p.Name))
+		if rel == "." || rel == ".." || filepath.IsAbs(rel) || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("invalid file name %q", p.Name)
+		}
+		targets[i] = filepath.Join(dir, rel)
+		if !overwrite && fileExists(targets[i]) {
+			return nil, fmt.Errorf("file exists: %s", targets[i])
+		}
+	}
+	var paths []string
+	for i, p := range parts {
+		path := targets[i]
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return paths, err
+		}
+		content := p.Content
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// JoinParts concatenates the parts of a bundle with a header line naming each file.
+// A single-part snippet is returned unchanged.
+func JoinParts(s Snippet) string {
+	parts := s.Parts()
+	if len(parts) == 1 {
+		return parts[0].Content
+	}
+	var b strings.Builder
+	for i, p := range parts {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# ==> %s <==\n", p.Name)
+		b.WriteString(p.Content)
+		if !strings.HasSuffix(p.Content, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,21 @@
+package snippets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateCategory checks that cat, a "/"-separated folder path, stays below
+// the directory it is joined to: no empty, "." or ".." segment, no hidden
+// folder and no backslash. The empty category (the root) is valid.
+func ValidateCategory(cat string) error {
+	if cat == "" {
+		return nil
+	}
+	for _, p := range strings.Split(cat, "/") {
+		if p == "" || p == "." || p == ".." || strings.HasPrefix(p, ".") || strings.ContainsRune(p, '\\') {
+			return fmt.Errorf("invalid category %q", cat)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package snippets
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Orders of the snippets of a cheat sheet.
+const (
+	OrderTitle   = "title"   // A → Z
+	OrderCreated = "created" // oldest first
+	OrderUsage   = "usage"   // most used first
+)
+
+// SortBy sorts list in place by order; ties are broken by title. usage is only
+// read for OrderUsage.
+func SortBy(list []Snippet, order string, usage map[string]Usage) error {
+	cmp := func(a, b Snippet) int { return 0 }
+	switch order {
+	case OrderTitle, "":
+	case OrderCreated:
+		cmp = func(a, b Snippet) int { return a.CreatedAt.Compare(b.CreatedAt) }
+	case OrderUsage:
+		cmp = func(a, b Snippet) int { return usage[b.ID].Count - usage[a.ID].Count }
+	default:
+		return fmt.Errorf("unknown order %q (want %s, %s or %s)", order, OrderTitle, OrderCreated, OrderUsage)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if c := cmp(list[i], list[j]); c != 0 {
+			return c < 0
+		}
+		return strings.ToLower(list[i].Title) < strings.ToLower(list[j].Title)
+	})
+	return nil
+}
+
+// Cheatsheet renders the snippets of one category as a Markdown document: the
+// category as title, a table of contents, then one section per snippet with
+// its tags, description and fenced code blocks. ParseCheatsheet reads it back.
+func Cheatsheet(category string, list []Snippet) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n\n", category)
+	anchors := map[string]int{}
+	for _, s := range list {
+		fmt.Fprintf(&b, "- [%s](#%s)\n", s.Title, anchor(s.Title, anchors))
+	}
+	for _, s := range list {
+		fmt.Fprintf(&b, "\n## %s\n\n", s.Title)
+		if s.ID != "" {
+			fmt.Fprintf(&b, "<!-- id: %s -->\n", s.ID)
+		}
+		if len(s.Tags) > 0 {
+			b.WriteString("Tags: `" + strings.Join(s.Tags, "`, `") + "`\n")
+		}
+		b.WriteString("\n")
+		if d := strings.TrimSpace(s.Description); d != "" {
+			b.WriteString(escapeHeadings(d) + "\n\n")
+		}
+		// As in snippet files, a newline is always added before the closing
+		// fence and dropped on import.
+		withBlock := contentBlock(s)
+		if withBlock {
+			writeFence(&b, s.Language, "", s.Content+"\n")
+		}
+		for i, f := range s.Files {
+			if i > 0 || withBlock {
+				b.WriteString("\n")
+			}
+			writeFence(&b, f.Language, f.Name, f.Content+"\n")
+		}
+	}
+	return b.Bytes()
+}
+
+// anchor returns the GitHub heading anchor of title; seen numbers repeated ones.
+func anchor(title string, seen map[string]int) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	a := b.String()
+	n := seen[a]
+	seen[a]++
+	if n > 0 {
+		return fmt.Sprintf("%s-%d", a, n)
+	}
+	return a
+}
+
+// ParseCheatsheet reads the snippets of a document written by Cheatsheet, or by
+// hand in the same shape: "# category", then "## title" sections holding
+// fenced code blocks, an optional "Tags:" line and the description as text
+// above the code. Only a "#" heading before the first section names the
+// category; headings inside code blocks are ignored.
+func ParseCheatsheet(data []byte) ([]Snippet, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	var out []Snippet
+	var category string
+	var cur *Snippet
+	var body []string
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		parseSection(cur, strings.Join(body, "\n"))
+		out = append(out, *cur)
+		cur, body = nil, nil
+	}
+	fence := ""
+	for _, line := range strings.Split(text, "\n") {
+		t := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(t), fence) && strings.Trim(strings.TrimSpace(t), fence[:1]) == "" {
+				fence = ""
+			}
+		} else if strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+			fence = t[:len(t)-len(strings.TrimLeft(t, t[:1]))]
+		} else if title, ok := strings.CutPrefix(t, "## "); ok {
+			flush()
+			cur = &Snippet{Title: strings.TrimSpace(title), Category: category}
+			continue
+		} else if c, ok := strings.CutPrefix(t, "# "); ok && cur == nil && len(out) == 0 {
+			flush()
+			category = strings.Trim(strings.TrimSpace(c), "/")
+			continue
+		}
+		if cur != nil {
+			body = append(body, line)
+		}
+	}
+	flush()
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no \"## title\" sections found")
+	}
+	return out, nil
+}
+
+// parseSection fills s from the body of its section.
+func parseSection(s *Snippet, body string) {
+	var rest []string
+	inCode := false
+	for _, line := range strings.Split(body, "\n") {
+		t := strings.TrimSpace(line)
+		// Meta lines come before the code.
+		inCode = inCode || strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~")
+		if inCode {
+			rest = append(rest, line)
+			continue
+		}
+		if id, ok := strings.CutPrefix(t, "<!-- id:"); ok {
+			s.ID = strings.TrimSpace(strings.TrimSuffix(id, "-->"))
+			continue
+		}
+		if tags, ok := strings.CutPrefix(t, "Tags:"); ok && s.Tags == nil {
+			for _, tag := range strings.Split(tags, ",") {
+				if tag = strings.Trim(strings.TrimSpace(tag), "`"); tag != "" {
+					s.Tags = append(s.Tags, tag)
+				}
+			}
+			continue
+		}
+		rest = append(rest, line)
+	}
+	desc, main, files, ok := splitBody(strings.Join(rest, "\n"))
+	for _, bl := range files {
+		s.Files = append(s.Files, File{Name: bl.file, Language: bl.lang, Content: bl.code})
+	}
+	switch {
+	case !ok:
+		s.Content = strings.TrimSpace(strings.Join(rest, "\n"))
+	case main != nil:
+		s.Content, s.Language = main.code, main.lang
+	}
+	s.Description = unescapeHeadings(desc)
+}
+
+// escapeHeadings puts a backslash before the "#" starting a description line
+// outside code blocks, so that it is not read back as a section or category
+// heading. Lines already starting with backslashes and "#" get one more, which
+// unescapeHeadings removes.
+func escapeHeadings(text string) string {
+	return mapOutsideFences(text, func(line string) string {
+		t := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(strings.TrimLeft(t, `\`), "#") {
+			return line[:len(line)-len(t)] + `\` + t
+		}
+		return line
+	})
+}
+
+// unescapeHeadings undoes escapeHeadings.
+func unescapeHeadings(text string) string {
+	return mapOutsideFences(text, func(line string) string {
+		t := strings.TrimLeft(line, " ")
+		if strings.HasPrefix(t, `\`) && strings.HasPrefix(strings.TrimLeft(t, `\`), "#") {
+			return line[:len(line)-len(t)] + t[1:]
+		}
+		return line
+	})
+}
+
+// mapOutsideFences applies fn to the lines of text that are not part of a
+// fenced code block.
+func mapOutsideFences(text string, fn func(string) string) string {
+	lines := strings.Split(text, "\n")
+	fence := ""
+	for i, line := range lines {
+		t := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+				fence = ""
+			}
+		case strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~"):
+			fence = t[:len(t)-len(strings.TrimLeft(t, t[:1]))]
+		default:
+			lines[i] = fn(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,140 @@
+package snippets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultSimilarity is the threshold above which two snippets are reported as near-duplicates.
+const DefaultSimilarity = 0.8
+
+// Duplicate pairs two snippets with similar content. Exact is set when their
+// normalized contents hash to the same value.
+type Duplicate struct {
+	A, B       Snippet
+	Similarity float64
+	Exact      bool
+}
+
+// NormalizedHash hashes the content of s (and of its files) with whitespace
+// differences ignored, so that re-indented copies of a command compare equal.
+func NormalizedHash(s Snippet) string {
+	var b strings.Builder
+	for _, p := range s.Parts() {
+		b.WriteString(normalizeContent(p.Content))
+		b.WriteString("\x00")
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeContent(c string) string {
+	var lines []string
+	for _, ln := range strings.Split(c, "\n") {
+		if f := strings.Fields(ln); len(f) > 0 {
+			lines = append(lines, strings.Join(f, " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Similarity returns the Jaccard index of the token sets of a and b, in [0, 1].
+func Similarity(a, b Snippet) float64 {
+	return jaccard(tokenSet(a), tokenSet(b))
+}
+
+// tokenSet splits content into identifiers/numbers and single punctuation characters.
+func tokenSet(s Snippet) map[string]bool {
+	set := map[string]bool{}
+	for _, p := range s.Parts() {
+		var cur strings.Builder
+		flush := func() {
+			if cur.Len() > 0 {
+				set[cur.String()] = true
+				cur.Reset()
+			}
+		}
+		for _, r := range p.Content {
+			switch {
+			case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+				cur.WriteRune(r)
+			case unicode.IsSpace(r):
+				flush()
+			default:
+				flush()
+				set[string(r)] = true
+			}
+		}
+		flush()
+	}
+	return set
+}
+
+// FindDuplicates returns the pairs of snippets whose similarity is at least
+// threshold, most similar first. Encrypted snippets are skipped.
+func FindDuplicates(all []Snippet, threshold float64) []Duplicate {
+	var plain []Snippet
+	for _, s := range all {
+		if !s.Encrypted {
+			plain = append(plain, s)
+		}
+	}
+	hashes := make([]string, len(plain))
+	tokens := make([]map[string]bool, len(plain))
+	for i, s := range plain {
+		hashes[i] = NormalizedHash(s)
+		tokens[i] = tokenSet(s)
+	}
+
+	var out []Duplicate
+	for i := range plain {
+		for j := i + 1; j < len(plain); j++ {
+			if hashes[i] == hashes[j] {
+				out = append(out, Duplicate{A: plain[i], B: plain[j], Similarity: 1, Exact: true})
+				continue
+			}
+			// Cheap bound: Jaccard ≤ min/max of the set sizes.
+			small, large := len(tokens[i]), len(tokens[j])
+			if small > large {
+				small, large = large, small
+			}
+			if large == 0 || float64(small)/float64(large) < threshold {
+				continue
+			}
+			if sim := jaccard(tokens[i], tokens[j]); sim >= threshold {
+				out = append(out, Duplicate{A: plain[i], B: plain[j], Similarity: sim})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Similarity > out[j].Similarity })
+	return out
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	inter := 0
+	for t := range a {
+		if b[t] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// Merge saves keep (already holding the fields picked from both snippets) and
+// moves drop to the trash.
+func (r *Repo) Merge(keep, drop Snippet) (Snippet, error) {
+	keep, err := r.Update(keep)
+	if err != nil {
+		return keep, err
+	}
+	if _, err := r.Trash(drop); err != nil {
+		return keep, err
+	}
+	return keep, nil
+}
@@ -0,0 +1,388 @@
+package snippets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity of a library issue found by Doctor.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// Issue is one problem found in the library. Fixable issues are solved by one of the report's fixes.
+type Issue struct {
+	Severity Severity
+	Code     string // short identifier, e.g. "duplicate-id"
+	Path     string
+	Message  string
+	Fixable  bool
+}
+
+// Fix is a pending repair: a file rewrite (possibly under a new path) or a directory removal.
+type Fix struct {
+	Path    string
+	NewPath string // differs from Path when the file is renamed
+	Before  []byte
+	After   []byte
+	Remove  bool // remove the (empty) directory at Path
+	Reasons []string
+}
+
+// Report is the result of Doctor.
+type Report struct {
+	Issues []Issue
+	Fixes  []Fix
+}
+
+type doctorFile struct {
+	path  string
+	raw   []byte
+	s     Snippet
+	from  int
+	fixed Snippet
+	// newPath is set when the file gets renamed after its title slug.
+	newPath string
+	why     []string
+}
+
+// Doctor inspects every file under the repo root and proposes fixes where possible.
+// Hidden files and directories (e.g. .git, the vault) are ignored.
+func (r *Repo) Doctor() (Report, error) {
+	var rep Report
+	var files []*doctorFile
+	var dirs []string
+	err := filepath.WalkDir(r.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if err := skipHidden(path, r.root, d); err != nil {
+				return err
+			}
+			if path != r.root {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		if _, ok := formatOf(path); !ok {
+			rep.add(SeverityInfo, "stray-file", path, "not a snippet file", false)
+			return nil
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		s, from, err := loadFile(path)
+		if errors.Is(err, errNoFrontMatter) {
+			rep.add(SeverityInfo, "stray-file", path, "Markdown file without front matter", false)
+			return nil
+		}
+		if err != nil {
+			rep.add(SeverityError, "unreadable", path, err.Error(), false)
+			return nil
+		}
+		files = append(files, &doctorFile{path: path, raw: raw, s: s, from: from, fixed: s})
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return rep, nil
+	}
+	if err != nil {
+		return rep, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	for _, f := range files {
+		r.checkFile(&rep, f)
+	}
+	checkDuplicateIDs(&rep, files)
+	checkLinks(&rep, files)
+	duplicateAliases(&rep, files)
+	planSlugRenames(files)
+
+	for _, f := range files {
+		if len(f.why) == 0 {
+			continue
+		}
+		newPath := f.path
+		if f.newPath != "" {
+			newPath = f.newPath
+		}
+		format, _ := formatOf(f.path)
+		after, err := encode(format, f.fixed)
+		if err != nil {
+			return rep, fmt.Errorf("%s: %w", f.path, err)
+		}
+		rep.Fixes = append(rep.Fixes, Fix{Path: f.path, NewPath: newPath, Before: f.raw, After: after, Reasons: f.why})
+	}
+
+	// Deepest first: a folder holding only empty folders is empty too.
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	empty := map[string]bool{}
+	for _, d := range dirs {
+		entries, err := os.ReadDir(d)
+		if err != nil {
+			continue
+		}
+		n := 0
+		for _, e := range entries {
+			if !empty[filepath.Join(d, e.Name())] {
+				n++
+			}
+		}
+		if n == 0 {
+			empty[d] = true
+			rep.add(SeverityInfo, "empty-folder", d, "empty folder", true)
+			rep.Fixes = append(rep.Fixes, Fix{Path: d, Remove: true, Reasons: []string{"remove empty folder"}})
+		}
+	}
+	return rep, nil
+}
+
+func (r *Repo) checkFile(rep *Report, f *doctorFile) {
+	s := f.s
+	if f.from < CurrentSchema {
+		rep.add(SeverityWarning, "old-schema", f.path, fmt.Sprintf("schema v%d, current is v%d", f.from, CurrentSchema), true)
+		f.why = append(f.why, "migrate schema")
+	}
+	if !utf8.Valid(f.raw) {
+		rep.add(SeverityError, "invalid-utf8", f.path, "file is not valid UTF-8", true)
+		f.why = append(f.why, "replace invalid UTF-8 sequences")
+	}
+	if strings.TrimSpace(s.Content) == "" && len(s.Files) == 0 {
+		rep.add(SeverityWarning, "empty-content", f.path, "snippet has no content", false)
+	}
+	if rel, err := filepath.Rel(r.root, filepath.Dir(f.path)); err == nil {
+		dir := filepath.ToSlash(rel)
+		if dir == "." {
+			dir = ""
+		}
+		if strings.Trim(s.Category, "/") != dir {
+			rep.add(SeverityWarning, "category-mismatch", f.path,
+				fmt.Sprintf("category %q but file is in %q", s.Category, dir), true)
+			f.fixed.Category = dir
+			f.why = append(f.why, "set category from folder")
+		}
+	}
+	if slug := Slugify(s.Title); strings.TrimSpace(s.Title) != "" && !matchesSlug(s.Slug(), slug) {
+		rep.add(SeverityInfo, "filename-mismatch", f.path, fmt.Sprintf("file name does not match title slug %q", slug), true)
+		f.newPath = slug // resolved to a free path by planSlugRenames
+		f.why = append(f.why, "rename file after title")
+	}
+}
+
+// matchesSlug accepts the slug itself or the slug with a numeric "-N" suffix.
+func matchesSlug(name, slug string) bool {
+	if name == slug {
+		return true
+	}
+	n, ok := strings.CutPrefix(name, slug+"-")
+	if !ok || n == "" {
+		return false
+	}
+	_, err := strconv.Atoi(n)
+	return err == nil
+}
+
+// checkDuplicateIDs reports missing IDs and IDs shared by several files. All but
+// the first file of a duplicate group get a fresh ID; file names are kept.
+func checkDuplicateIDs(rep *Report, files []*doctorFile) {
+	first := map[string]string{}
+	for _, f := range files {
+		id := f.s.ID
+		switch p, ok := first[id]; {
+		case id == "":
+			rep.add(SeverityError, "missing-id", f.path, "snippet has no id", true)
+		case ok:
+			rep.add(SeverityError, "duplicate-id", f.path, fmt.Sprintf("id %q also used by %s", id, p), true)
+		default:
+			first[id] = f.path
+			continue
+		}
+		f.fixed.ID = NewID()
+		f.why = append(f.why, "assign a new id")
+	}
+}
+
+// checkLinks reports links to snippets that no longer exist. Dangling entries
+// of the related list are dropped by the fix; [[ref]] links in descriptions
+// are left for the user to edit.
+func checkLinks(rep *Report, files []*doctorFile) {
+	all := make([]Snippet, len(files))
+	for i, f := range files {
+		all[i] = f.s
+	}
+	for _, f := range files {
+		_, dangling := ResolveLinks(all, f.s)
+		if len(dangling) == 0 {
+			continue
+		}
+		gone := map[string]bool{}
+		for _, ref := range dangling {
+			gone[ref] = true
+		}
+		var kept []string
+		for _, ref := range f.s.Related {
+			if gone[ref] {
+				rep.add(SeverityWarning, "dangling-link", f.path, fmt.Sprintf("related snippet %q does not exist", ref), true)
+			} else {
+				kept = append(kept, ref)
+			}
+		}
+		if len(kept) != len(f.s.Related) {
+			f.fixed.Related = kept
+			f.why = append(f.why, "drop dangling related links")
+		}
+		for _, ref := range InlineLinks(f.s.Description) {
+			if gone[ref] {
+				rep.add(SeverityWarning, "dangling-link", f.path, fmt.Sprintf("description links to missing snippet [[%s]]", ref), false)
+				delete(gone, ref)
+			}
+		}
+	}
+}
+
+// duplicateAliases reports aliases shared by several files, which Find can
+// only resolve to the first one.
+func duplicateAliases(rep *Report, files []*doctorFile) {
+	first := map[string]string{}
+	for _, f := range files {
+		for _, a := range NormalizeAliases(f.s.Aliases) {
+			if p, ok := first[a]; ok {
+				rep.add(SeverityWarning, "duplicate-alias", f.path, fmt.Sprintf("alias %q also used by %s", a, p), false)
+				continue
+			}
+			first[a] = f.path
+		}
+	}
+}
+
+// planSlugRenames turns the slugs requested by checkFile into free paths,
+// taking both existing files and the other planned renames into account.
+func planSlugRenames(files []*doctorFile) {
+	planned := map[string]bool{}
+	for _, f := range files {
+		if f.newPath == "" {
+			continue
+		}
+		dir, ext, slug := filepath.Dir(f.path), filepath.Ext(f.path), f.newPath
+		name := slug
+		for n := 2; ; n++ {
+			p := filepath.Join(dir, name+ext)
+			if !planned[p] && !fileExists(filepath.Join(dir, name+".json")) && !fileExists(filepath.Join(dir, name+".md")) {
+				f.newPath = p
+				planned[p] = true
+				break
+			}
+			name = fmt.Sprintf("%s-%d", slug, n)
+		}
+	}
+}
+
+func (rep *Report) add(sev Severity, code, path, msg string, fixable bool) {
+	rep.Issues = append(rep.Issues, Issue{Severity: sev, Code: code, Path: path, Message: msg, Fixable: fixable})
+}
+
+// Apply performs the fix on disk.
+func (f Fix) Apply() error {
+	if f.Remove {
+		return os.Remove(f.Path)
+	}
+	if f.NewPath != f.Path && fileExists(f.NewPath) {
+		return fmt.Errorf("cannot rename %s: %s exists", f.Path, f.NewPath)
+	}
+	if err := os.WriteFile(f.NewPath, f.After, 0o644); err != nil {
+		return err
+	}
+	if f.NewPath != f.Path {
+		return os.Remove(f.Path)
+	}
+	return nil
+}
+
+// Diff renders the fix as a line diff for dry runs.
+func (f Fix) Diff() string {
+	var b strings.Builder
+	if f.Remove {
+		fmt.Fprintf(&b, "rmdir %s\n", f.Path)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", f.Path, f.NewPath)
+	for _, ln := range lineDiff(splitLines(f.Before), splitLines(f.After)) {
+		b.WriteString(ln)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func splitLines(b []byte) []string {
+	b = bytes.TrimSuffix(b, []byte("\n"))
+	return strings.Split(strings.ToValidUTF8(string(b), "�"), "\n")
+}
+
+// lineDiff returns the removed (-) and added (+) lines between a and b, using an LCS table.
+func lineDiff(a, b []string) []string {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out []string
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "-"+a[i])
+			i++
+		default:
+			out = append(out, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		out = append(out, "-"+a[i])
+	}
+	for ; j < m; j++ {
+		out = append(out, "+"+b[j])
+	}
+	return out
+}
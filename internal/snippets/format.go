@@ -0,0 +1,64 @@
+package snippets
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the on-disk encoding of a snippet file.
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "md"
+)
+
+// Ext returns the file extension for the format, including the dot.
+func (f Format) Ext() string { return "." + string(f) }
+
+// ParseFormat accepts "json", "md" or "markdown".
+func ParseFormat(v string) (Format, bool) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "json":
+		return FormatJSON, true
+	case "md", "markdown":
+		return FormatMarkdown, true
+	}
+	return "", false
+}
+
+// FormatFromEnv reads SNIPSTER_FORMAT, the format used for new snippets. Defaults to JSON.
+func FormatFromEnv() Format {
+	if f, ok := ParseFormat(os.Getenv("SNIPSTER_FORMAT")); ok {
+		return f
+	}
+	return FormatJSON
+}
+
+// formatOf returns the format of a snippet file from its extension.
+func formatOf(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, true
+	case ".md":
+		return FormatMarkdown, true
+	}
+	return "", false
+}
+
+func encode(f Format, s Snippet) ([]byte, error) {
+	if f == FormatMarkdown {
+		return marshalMarkdown(s)
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// toJSON returns the JSON document of a snippet file, whatever its format.
+func toJSON(f Format, b []byte) ([]byte, error) {
+	if f == FormatMarkdown {
+		return markdownToJSON(b)
+	}
+	return b, nil
+}
@@ -0,0 +1,102 @@
+package snippets
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Interpreters named on a shebang line, mapped to a language.
+var shebangLangs = map[string]string{
+	"bash": "bash", "sh": "sh", "zsh": "zsh", "fish": "fish",
+	"python": "python", "python3": "python", "node": "javascript", "ruby": "ruby",
+}
+
+// Commands that usually start a shell one-liner.
+var shellCommands = map[string]bool{
+	"cd": true, "ls": true, "cat": true, "echo": true, "export": true, "sudo": true, "curl": true,
+	"wget": true, "git": true, "docker": true, "kubectl": true, "helm": true, "npm": true, "npx": true,
+	"yarn": true, "pnpm": true, "go": true, "make": true, "ssh": true, "scp": true, "rsync": true,
+	"grep": true, "find": true, "awk": true, "sed": true, "tar": true, "chmod": true, "chown": true,
+	"mkdir": true, "rm": true, "cp": true, "mv": true, "brew": true, "apt": true, "apt-get": true,
+	"systemctl": true, "journalctl": true, "terraform": true, "aws": true, "gcloud": true, "psql": true,
+}
+
+// Ordered content rules; the first match wins.
+var langRules = []struct {
+	lang string
+	re   *regexp.Regexp
+}{
+	{"go", regexp.MustCompile(`(?m)^package \w+$|^func (\(\w+ \*?\w+\) )?\w+\(|:= `)},
+	{"dockerfile", regexp.MustCompile(`(?m)^FROM \S+`)},
+	{"sql", regexp.MustCompile(`(?i)^\s*(select\s.+\sfrom\s|insert\s+into\s|update\s+\w+\s+set\s|delete\s+from\s|create\s+(table|index|view)\s|alter\s+table\s)`)},
+	{"typescript", regexp.MustCompile(`(?m)^\s*(export\s+)?(interface|type)\s+\w+\s*[={]|:\s*(string|number|boolean)\b`)},
+	{"javascript", regexp.MustCompile(`(?m)^\s*(const|let|var)\s+\w+\s*=|=>|\bfunction\s*\w*\(|require\(|console\.log`)},
+	{"python", regexp.MustCompile(`(?m)^\s*(def|class)\s+\w+.*:\s*$|^\s*(from\s+\S+\s+)?import\s+\w+|print\(`)},
+	{"html", regexp.MustCompile(`(?i)^\s*<(!doctype|html|div|head|body|span|p|a|ul|section)\b`)},
+	{"yaml", regexp.MustCompile(`(?m)^(apiVersion|kind|version|services|name):\s|^[a-z_][\w-]*:\s*\n\s+[a-z_-]+:`)},
+}
+
+// GuessLanguage returns a likely language for content, or "" when unsure.
+func GuessLanguage(content string) string {
+	c := strings.TrimSpace(content)
+	if c == "" {
+		return ""
+	}
+	first, _, _ := strings.Cut(c, "\n")
+	if strings.HasPrefix(first, "#!") {
+		f := strings.Fields(strings.TrimPrefix(first, "#!"))
+		if len(f) > 0 {
+			name := f[0][strings.LastIndex(f[0], "/")+1:]
+			if name == "env" && len(f) > 1 {
+				name = f[1]
+			}
+			if l, ok := shebangLangs[name]; ok {
+				return l
+			}
+		}
+	}
+	if (strings.HasPrefix(c, "{") || strings.HasPrefix(c, "[")) && json.Valid([]byte(c)) {
+		return "json"
+	}
+	for _, r := range langRules {
+		if r.re.MatchString(c) {
+			return r.lang
+		}
+	}
+	cmd := strings.TrimPrefix(strings.TrimSpace(first), "$ ")
+	if f := strings.Fields(cmd); len(f) > 0 && shellCommands[f[0]] {
+		return "bash"
+	}
+	return ""
+}
+
+// Languages offered for autocompletion besides those already in the library.
+var commonLanguages = []string{
+	"bash", "c", "cpp", "csharp", "css", "dockerfile", "fish", "go", "graphql", "html", "java",
+	"javascript", "json", "kotlin", "lua", "makefile", "markdown", "nginx", "php", "powershell",
+	"python", "ruby", "rust", "sh", "sql", "swift", "toml", "typescript", "xml", "yaml", "zsh",
+}
+
+// KnownLanguages returns the common languages and those used by all, lowercased
+// and sorted.
+func KnownLanguages(all []Snippet) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	add := func(l string) {
+		l = strings.ToLower(strings.TrimSpace(l))
+		if l != "" && !seen[l] {
+			seen[l] = true
+			out = append(out, l)
+		}
+	}
+	for _, l := range commonLanguages {
+		add(l)
+	}
+	for _, s := range all {
+		add(s.Language)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,108 @@
+package snippets
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Crockford's base32 alphabet, as used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewID returns a ULID-style identifier: 26 characters encoding a 48-bit
+// millisecond timestamp followed by 80 random bits, so IDs sort by creation time.
+func NewID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	binary.BigEndian.PutUint64(b[:8], ms<<16)
+	if _, err := rand.Read(b[6:]); err != nil {
+		panic(fmt.Sprintf("snippets: read random: %v", err))
+	}
+	// 128 bits in 26 groups of 5, the first group holding the 3 leading bits.
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// Slug returns the human part of the snippet file name: the file base name
+// when the snippet was loaded from disk, the title slug otherwise.
+func (s Snippet) Slug() string {
+	if s.Path != "" {
+		return strings.TrimSuffix(filepath.Base(s.Path), filepath.Ext(s.Path))
+	}
+	return Slugify(s.Title)
+}
+
+// Matches reports whether ref names s, by ID, alias or file slug.
+func (s Snippet) Matches(ref string) bool {
+	return ref != "" && (s.ID == ref || HasAlias(s, ref) || s.Slug() == ref)
+}
+
+// Find looks a snippet up by ID, then by alias, then by file slug, so that an
+// ID always wins.
+func Find(all []Snippet, ref string) (Snippet, bool) {
+	for _, s := range all {
+		if s.ID == ref {
+			return s, true
+		}
+	}
+	for _, s := range all {
+		if HasAlias(s, ref) {
+			return s, true
+		}
+	}
+	for _, s := range all {
+		if s.Matches(ref) {
+			return s, true
+		}
+	}
+	return Snippet{}, false
+}
+
+var translit = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a",
+	'æ': "ae", 'ç': "c", 'č': "c", 'ć': "c", 'ď': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ě': "e", 'ę': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ł': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'œ': "oe",
+	'ř': "r", 'š': "s", 'ś': "s", 'ß': "ss", 'ť': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u",
+	'ý': "y", 'ÿ': "y", 'ž': "z", 'ź': "z", 'ż': "z",
+}
+
+var slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns a title into a file name: lower case ASCII words joined by
+// dashes, accented letters transliterated ("Créer" → "creer"). Titles with
+// nothing to keep (CJK, Cyrillic, "!!!") get "snippet-" and a hash of the
+// title, so the same title always gives the same name.
+func Slugify(in string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(in)) {
+		if t, ok := translit[r]; ok {
+			b.WriteString(t)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	x := slugRe.ReplaceAllString(b.String(), "-")
+	x = strings.Trim(x, "-")
+	if x == "" {
+		h := fnv.New32a()
+		h.Write([]byte(strings.TrimSpace(in)))
+		x = fmt.Sprintf("snippet-%08x", h.Sum32())
+	}
+	return x
+}
@@ -0,0 +1,74 @@
+package snippets
+
+import (
+	"regexp"
+	"strings"
+)
+
+// linkRe matches a [[ref]] link to another snippet in a description; ref is an
+// ID, an alias or a file slug, like the references accepted by Find.
+var linkRe = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// InlineLinks returns the refs of the [[ref]] links of text, in order.
+func InlineLinks(text string) []string {
+	var out []string
+	for _, m := range linkRe.FindAllStringSubmatch(text, -1) {
+		out = append(out, strings.TrimSpace(m[1]))
+	}
+	return out
+}
+
+// ReplaceLinks replaces every [[ref]] link of text with repl(ref).
+func ReplaceLinks(text string, repl func(ref string) string) string {
+	return linkRe.ReplaceAllStringFunc(text, func(m string) string {
+		return repl(strings.TrimSpace(m[2 : len(m)-2]))
+	})
+}
+
+// Links returns the references of s to other snippets: its related list, then
+// the links of its description, without duplicates.
+func Links(s Snippet) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, ref := range append(append([]string(nil), s.Related...), InlineLinks(s.Description)...) {
+		if ref != "" && !seen[ref] {
+			seen[ref] = true
+			out = append(out, ref)
+		}
+	}
+	return out
+}
+
+// ResolveLinks splits the links of s into the snippets of all they point to
+// and the refs that match nothing (deleted or renamed snippets).
+func ResolveLinks(all []Snippet, s Snippet) (found []Snippet, dangling []string) {
+	seen := map[string]bool{s.ID: true}
+	for _, ref := range Links(s) {
+		t, ok := Find(all, ref)
+		switch {
+		case !ok:
+			dangling = append(dangling, ref)
+		case !seen[t.ID]:
+			seen[t.ID] = true
+			found = append(found, t)
+		}
+	}
+	return found, dangling
+}
+
+// Backlinks returns the snippets of all linking to s.
+func Backlinks(all []Snippet, s Snippet) []Snippet {
+	var out []Snippet
+	for _, o := range all {
+		if o.ID == s.ID {
+			continue
+		}
+		for _, ref := range Links(o) {
+			if t, ok := Find(all, ref); ok && t.ID == s.ID {
+				out = append(out, o)
+				break
+			}
+		}
+	}
+	return out
+}
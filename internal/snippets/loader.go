@@ -3,22 +3,31 @@ package snippets
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
 type Repo struct {
-	root string
+	root   string
+	format Format
+	// Rewrite files upgraded by a schema migration while loading them.
+	migrateOnLoad bool
 }
 
-func NewRepo(root string) *Repo { return &Repo{root: root} }
+func NewRepo(root string) *Repo { return &Repo{root: root, format: FormatJSON} }
 
 func (r *Repo) Root() string { return r.root }
 
-// LoadAll scans recursively for .json snippet files.
+// SetFormat selects the file format used for new snippets.
+func (r *Repo) SetFormat(f Format) { r.format = f }
+
+// SetMigrateOnLoad makes LoadAll rewrite files from older schema versions.
+func (r *Repo) SetMigrateOnLoad(v bool) { r.migrateOnLoad = v }
+
+// LoadAll scans recursively for .json and .md snippet files.
 func (r *Repo) LoadAll() ([]Snippet, error) {
 	var out []Snippet
 	err := filepath.WalkDir(r.root, func(path string, d fs.DirEntry, err error) error {
@@ -26,32 +35,110 @@ func (r *Repo) LoadAll() ([]Snippet, error) {
 			return err
 		}
 		if d.IsDir() {
+			return skipHidden(path, r.root, d)
+		}
+		if _, ok := formatOf(d.Name()); !ok || strings.HasPrefix(d.Name(), ".") {
 			return nil
 		}
-		if !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+		s, from, err := loadFile(path)
+		if errors.Is(err, errNoFrontMatter) {
+			// Plain Markdown notes may live next to snippets.
 			return nil
 		}
-		b, err := os.ReadFile(path)
 		if err != nil {
 			return err
 		}
-		var s Snippet
-		if err := json.Unmarshal(b, &s); err != nil {
+		if from < CurrentSchema && r.migrateOnLoad {
+			if err := writeSnippet(path, s); err != nil {
+				return err
+			}
+		}
+		out = append(out, s)
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return []Snippet{}, nil
+	}
+	return out, err
+}
+
+// Migrate rewrites every snippet file older than CurrentSchema and returns their paths.
+// With dryRun, files are only reported.
+func (r *Repo) Migrate(dryRun bool) ([]string, error) {
+	var out []string
+	err := filepath.WalkDir(r.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
 			return err
 		}
-		s.Path = path
-		// Best-effort parse timestamps if zero strings were used
-		if s.CreatedAt.IsZero() {
-			s.CreatedAt = time.Now().UTC()
+		if d.IsDir() {
+			return skipHidden(path, r.root, d)
 		}
-		if s.UpdatedAt.IsZero() {
-			s.UpdatedAt = s.CreatedAt
+		if _, ok := formatOf(d.Name()); !ok || strings.HasPrefix(d.Name(), ".") {
+			return nil
 		}
-		out = append(out, s)
+		s, from, err := loadFile(path)
+		if errors.Is(err, errNoFrontMatter) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if from >= CurrentSchema {
+			return nil
+		}
+		if !dryRun {
+			if err := writeSnippet(path, s); err != nil {
+				return err
+			}
+		}
+		out = append(out, path)
 		return nil
 	})
 	if errors.Is(err, os.ErrNotExist) {
-		return []Snippet{}, nil
+		return nil, nil
 	}
 	return out, err
 }
+
+// skipHidden skips hidden directories below root (.git, the trash…). Hidden
+// files (.vault, .usage.json…) are never snippets either.
+func skipHidden(path, root string, d fs.DirEntry) error {
+	if path != root && strings.HasPrefix(d.Name(), ".") {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// loadFile reads and migrates one snippet file. It also returns the schema
+// version found on disk.
+func loadFile(path string) (Snippet, int, error) {
+	var s Snippet
+	f, _ := formatOf(path)
+	st, err := os.Stat(path)
+	if err != nil {
+		return s, 0, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return s, 0, err
+	}
+	raw, err := toJSON(f, b)
+	if err != nil {
+		if errors.Is(err, errNoFrontMatter) {
+			return s, 0, err
+		}
+		return s, 0, fmt.Errorf("%s: %w", path, err)
+	}
+	raw, from, err := migrate(raw, fileInfo{modTime: st.ModTime()})
+	if err != nil {
+		return s, 0, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return s, 0, fmt.Errorf("%s: %w", path, err)
+	}
+	s.Path = path
+	if s.UpdatedAt.IsZero() {
+		s.UpdatedAt = s.CreatedAt
+	}
+	return s, from, nil
+}
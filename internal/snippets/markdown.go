@@ -0,0 +1,413 @@
+package snippets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// errNoFrontMatter marks Markdown files that are not snippets (e.g. a README in the library).
+var errNoFrontMatter = errors.New("no front matter")
+
+// Front matter keys written first, in this order; any other field follows alphabetically.
+var frontMatterOrder = []string{"schema_version", "id", "title", "category", "language", "tags", "aliases", "source_url", "author", "license", "related", "encrypted", "remotes", "created_at", "updated_at"}
+
+// marshalMarkdown renders a snippet as YAML-like front matter followed by its
+// description and its content in a fenced code block. Front matter fields
+// mirror the JSON encoding.
+func marshalMarkdown(s Snippet) ([]byte, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "content")
+	delete(fields, "files")
+	delete(fields, "description")
+
+	var b bytes.Buffer
+	b.WriteString("---\n")
+	for _, k := range frontMatterKeys(fields) {
+		v, ok, err := yamlValue(fields[k])
+		if err != nil {
+			return nil, fmt.Errorf("front matter %s: %w", k, err)
+		}
+		if ok {
+			fmt.Fprintf(&b, "%s: %s\n", k, v)
+		}
+	}
+	b.WriteString("---\n\n")
+	if d := strings.TrimSpace(s.Description); d != "" {
+		b.WriteString(d + "\n\n")
+	}
+
+	// A newline is always added before the closing fence, so that the content
+	// reads back byte for byte, trailing newline included.
+	withBlock := contentBlock(s)
+	if withBlock {
+		writeFence(&b, s.Language, "", s.Content+"\n")
+	}
+	// Bundle files follow as extra blocks tagged with file=<name>.
+	for i, f := range s.Files {
+		if i > 0 || withBlock {
+			b.WriteString("\n")
+		}
+		writeFence(&b, f.Language, f.Name, f.Content+"\n")
+	}
+	return b.Bytes(), nil
+}
+
+// contentBlock reports whether the content of s gets a fenced block of its own.
+// An empty one is still written for bundles whose description has code blocks,
+// since the last block without a file attribute is read back as the content.
+func contentBlock(s Snippet) bool {
+	return s.Content != "" || len(s.Files) == 0 || len(parseFences(s.Description)) > 0
+}
+
+func writeFence(b *bytes.Buffer, lang, file, content string) {
+	fence := fenceFor(content)
+	info := lang
+	if file != "" {
+		if info == "" {
+			info = "text"
+		}
+		info += " file=" + strconv.Quote(file)
+	}
+	b.WriteString(fence + info + "\n")
+	b.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(fence + "\n")
+}
+
+// markdownToJSON parses a file written by marshalMarkdown (or by hand in the same
+// shape) into the equivalent JSON document.
+func markdownToJSON(data []byte) ([]byte, error) {
+	// CRLF line ends are accepted in the front matter; the body is left as is,
+	// since the content may need its carriage returns.
+	text := string(data)
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		rest, ok = strings.CutPrefix(text, "---\r\n")
+	}
+	if !ok {
+		return nil, errNoFrontMatter
+	}
+	fm, body, ok := cutFrontMatter(rest)
+	if !ok {
+		return nil, errors.New("unterminated front matter")
+	}
+
+	fields := map[string]json.RawMessage{}
+	for n, line := range strings.Split(fm, "\n") {
+		t := strings.TrimSpace(line)
+		if t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(t, ":")
+		if !ok {
+			return nil, fmt.Errorf("front matter line %d: missing ':'", n+2)
+		}
+		raw, err := jsonFromYAML(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("front matter %s: %w", strings.TrimSpace(k), err)
+		}
+		fields[strings.TrimSpace(k)] = raw
+	}
+
+	var content, lang string
+	var files []File
+	desc, main, blocks, ok := splitBody(body)
+	for _, bl := range blocks {
+		files = append(files, File{Name: bl.file, Language: bl.lang, Content: bl.code})
+	}
+	switch {
+	case !ok:
+		content = strings.TrimSpace(body)
+	case main != nil:
+		content, lang = main.code, main.lang
+	}
+	if desc != "" {
+		fields["description"], _ = json.Marshal(desc)
+	}
+	fields["content"], _ = json.Marshal(content)
+	if len(files) > 0 {
+		fields["files"], _ = json.Marshal(files)
+	}
+	// The fence info string stands in for a missing language.
+	if l, ok := fields["language"]; (!ok || string(l) == `""` || string(l) == "null") && lang != "" {
+		fields["language"], _ = json.Marshal(lang)
+	}
+	return json.Marshal(fields)
+}
+
+// cutFrontMatter splits rest, the file after the opening "---" line, at the
+// closing "---" line.
+func cutFrontMatter(rest string) (fm, body string, ok bool) {
+	for off := 0; ; {
+		line, next, more := rest[off:], len(rest), false
+		if i := strings.IndexByte(rest[off:], '\n'); i >= 0 {
+			line, next, more = rest[off:off+i], off+i+1, true
+		}
+		if strings.TrimSuffix(line, "\r") == "---" {
+			return rest[:off], rest[next:], true
+		}
+		if !more {
+			return "", "", false
+		}
+		off = next
+	}
+}
+
+func frontMatterKeys(fields map[string]json.RawMessage) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, k := range frontMatterOrder {
+		if _, ok := fields[k]; ok {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	var rest []string
+	for k := range fields {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// yamlValue converts a JSON value into a front matter value. ok is false for null.
+func yamlValue(raw json.RawMessage) (string, bool, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", false, err
+	}
+	switch x := v.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return yamlScalar(x, false), true, nil
+	case []any:
+		items := make([]string, 0, len(x))
+		for _, it := range x {
+			str, ok := it.(string)
+			if !ok {
+				// Not a plain string list: JSON is valid flow YAML.
+				return string(raw), true, nil
+			}
+			items = append(items, yamlScalar(str, true))
+		}
+		return "[" + strings.Join(items, ", ") + "]", true, nil
+	default:
+		return string(raw), true, nil
+	}
+}
+
+// yamlScalar quotes s when a plain scalar would be ambiguous.
+func yamlScalar(s string, inList bool) string {
+	if needsQuote(s) || (inList && strings.ContainsAny(s, ",[]")) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func needsQuote(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.ContainsAny(s, "\n\t") {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "null", "~", "yes", "no":
+		return true
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// jsonFromYAML converts a front matter value back to JSON.
+func jsonFromYAML(v string) (json.RawMessage, error) {
+	switch {
+	case v == "" || v == "~":
+		return json.RawMessage("null"), nil
+	case json.Valid([]byte(v)):
+		return json.RawMessage(v), nil
+	case strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]"):
+		var items []string
+		for _, it := range splitFlowList(v[1 : len(v)-1]) {
+			s, err := unquoteScalar(it)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, s)
+		}
+		return json.Marshal(items)
+	default:
+		s, err := unquoteScalar(v)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(s)
+	}
+}
+
+func unquoteScalar(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	switch {
+	case strings.HasPrefix(v, `"`):
+		return strconv.Unquote(v)
+	case strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'") && len(v) >= 2:
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'"), nil
+	default:
+		return v, nil
+	}
+}
+
+// splitFlowList splits "a, \"b, c\", d" on commas outside quotes.
+func splitFlowList(s string) []string {
+	var out []string
+	var cur strings.Builder
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == ',':
+			if t := strings.TrimSpace(cur.String()); t != "" {
+				out = append(out, t)
+			}
+			cur.Reset()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if t := strings.TrimSpace(cur.String()); t != "" {
+		out = append(out, t)
+	}
+	return out
+}
+
+// fenceFor returns a backtick fence longer than any backtick run opening a line of content.
+func fenceFor(content string) string {
+	longest := 0
+	for _, ln := range strings.Split(content, "\n") {
+		ln = strings.TrimLeft(ln, " ")
+		n := len(ln) - len(strings.TrimLeft(ln, "`"))
+		if n > longest {
+			longest = n
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// splitBody splits the body of a snippet into its description, its content
+// block and its file blocks. The content is the last block without a file
+// attribute, so that code examples in the description stay there; the
+// description is the text above the first snippet block. ok is false when
+// body has no fenced block at all.
+func splitBody(body string) (desc string, main *fencedBlock, files []fencedBlock, ok bool) {
+	blocks := parseFences(body)
+	if len(blocks) == 0 {
+		return "", nil, nil, false
+	}
+	for i := range blocks {
+		if blocks[i].file == "" {
+			main = &blocks[i]
+		}
+	}
+	start := -1
+	if main != nil {
+		start = main.start
+	}
+	for _, bl := range blocks {
+		if bl.file != "" {
+			files = append(files, bl)
+			if start < 0 || bl.start < start {
+				start = bl.start
+			}
+		}
+	}
+	lines := strings.Split(body, "\n")
+	return strings.TrimSpace(strings.Join(lines[:start], "\n")), main, files, true
+}
+
+// fencedBlock is a fenced code block; start is the line index of its opening fence.
+type fencedBlock struct {
+	lang, file, code string
+	start            int
+}
+
+// parseFences extracts the fenced code blocks of body with their info-string
+// language and optional file="name" attribute.
+func parseFences(body string) []fencedBlock {
+	var out []fencedBlock
+	lines := strings.Split(body, "\n")
+	for i := 0; i < len(lines); i++ {
+		t := strings.TrimLeft(lines[i], " ")
+		ch := byte(0)
+		if strings.HasPrefix(t, "```") {
+			ch = '`'
+		} else if strings.HasPrefix(t, "~~~") {
+			ch = '~'
+		}
+		if ch == 0 {
+			continue
+		}
+		n := len(t) - len(strings.TrimLeft(t, string(ch)))
+		bl := fencedBlock{start: i}
+		info := strings.TrimSpace(t[n:])
+		if f := strings.Fields(info); len(f) > 0 {
+			bl.lang = f[0]
+			if bl.lang == "text" {
+				bl.lang = ""
+			}
+		}
+		if j := strings.Index(info, "file="); j >= 0 {
+			v := info[j+len("file="):]
+			if name, err := strconv.Unquote(v); err == nil {
+				bl.file = name
+			} else if f := strings.Fields(v); len(f) > 0 {
+				bl.file = f[0]
+			}
+		}
+		var code []string
+		crlf := false
+		for i++; i < len(lines); i++ {
+			ct := strings.TrimSpace(lines[i])
+			if len(ct) >= n && strings.Trim(ct, string(ch)) == "" {
+				crlf = strings.HasSuffix(lines[i], "\r")
+				break
+			}
+			code = append(code, lines[i])
+		}
+		bl.code = strings.Join(code, "\n")
+		// In a file saved with CRLF line ends, the line end before the closing
+		// fence is "\r\n".
+		if crlf {
+			bl.code = strings.TrimSuffix(bl.code, "\r")
+		}
+		out = append(out, bl)
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package snippets
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Placeholder is a named hole in a snippet's content, written as {{name}} or
+// {{name:default}}.
+type Placeholder struct {
+	Name    string
+	Default string
+}
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*(?::([^}]*))?\}\}`)
+
+// Placeholders returns the distinct placeholders of content in order of first appearance.
+// When a name appears several times, the first non-empty default wins.
+func Placeholders(content string) []Placeholder {
+	var out []Placeholder
+	seen := map[string]int{}
+	for _, m := range placeholderRe.FindAllStringSubmatch(content, -1) {
+		name, def := m[1], strings.TrimSpace(m[2])
+		if i, ok := seen[name]; ok {
+			if out[i].Default == "" {
+				out[i].Default = def
+			}
+			continue
+		}
+		seen[name] = len(out)
+		out = append(out, Placeholder{Name: name, Default: def})
+	}
+	return out
+}
+
+// FillPlaceholders substitutes every placeholder with its value from values,
+// falling back to the placeholder default. Unknown names without a default are left as-is.
+func FillPlaceholders(content string, values map[string]string) string {
+	return placeholderRe.ReplaceAllStringFunc(content, func(s string) string {
+		m := placeholderRe.FindStringSubmatch(s)
+		if v, ok := values[m[1]]; ok && v != "" {
+			return v
+		}
+		if def := strings.TrimSpace(m[2]); def != "" {
+			return def
+		}
+		if _, ok := values[m[1]]; ok {
+			return ""
+		}
+		return s
+	})
+}
+
+// TabStops rewrites content in the snippet syntax of LSP and TextMate editors:
+// each placeholder becomes a numbered tab stop, ${1:default} (the name stands in
+// for a missing default), repeated names share their number, and the cursor
+// ends after the snippet ($0). Literal "$", "}" and "\" are escaped.
+func TabStops(content string) string {
+	var b strings.Builder
+	num := map[string]int{}
+	defaults := map[string]string{}
+	for _, p := range Placeholders(content) {
+		num[p.Name] = len(num) + 1
+		defaults[p.Name] = p.Default
+	}
+	escape := strings.NewReplacer(`\`, `\\`, `$`, `\$`, `}`, `\}`)
+	last := 0
+	for _, loc := range placeholderRe.FindAllStringSubmatchIndex(content, -1) {
+		b.WriteString(escape.Replace(content[last:loc[0]]))
+		name := content[loc[2]:loc[3]]
+		def := defaults[name]
+		if def == "" {
+			def = name
+		}
+		fmt.Fprintf(&b, "${%d:%s}", num[name], escape.Replace(def))
+		last = loc[1]
+	}
+	b.WriteString(escape.Replace(content[last:]))
+	b.WriteString("$0")
+	return b.String()
+}
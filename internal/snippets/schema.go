@@ -0,0 +1,145 @@
+package snippets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CurrentSchema is the schema version written by this build.
+// Files without schema_version are version 0.
+const CurrentSchema = 1
+
+// migration upgrades a decoded snippet document from version from to from+1.
+type migration struct {
+	from int
+	name string
+	up   func(doc map[string]any, info fileInfo) error
+}
+
+// fileInfo gives migrations access to file metadata.
+type fileInfo struct {
+	modTime time.Time
+}
+
+// migrations must stay ordered by from, one step per version.
+var migrations = []migration{
+	{from: 0, name: "timestamps from file modification time", up: migrateV0},
+}
+
+// migrateV0 replaces missing timestamps with the file modification time, instead of
+// the load time, so that ordering metadata no longer changes on every load.
+func migrateV0(doc map[string]any, info fileInfo) error {
+	created, _ := doc["created_at"].(string)
+	if t, err := time.Parse(time.RFC3339Nano, created); err != nil || t.IsZero() {
+		created = info.modTime.UTC().Format(time.RFC3339Nano)
+		doc["created_at"] = created
+	}
+	updated, _ := doc["updated_at"].(string)
+	if t, err := time.Parse(time.RFC3339Nano, updated); err != nil || t.IsZero() {
+		doc["updated_at"] = created
+	}
+	return nil
+}
+
+// migrate upgrades a JSON snippet document to CurrentSchema. It returns the
+// original version; documents from a newer schema are returned untouched.
+func migrate(raw []byte, info fileInfo) ([]byte, int, error) {
+	var doc map[string]any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, 0, err
+	}
+	from := 0
+	if v, ok := doc["schema_version"].(json.Number); ok {
+		n, err := v.Int64()
+		if err != nil {
+			return nil, 0, fmt.Errorf("schema_version: %w", err)
+		}
+		from = int(n)
+	}
+	if from >= CurrentSchema {
+		return raw, from, nil
+	}
+	for _, m := range migrations {
+		if m.from < from {
+			continue
+		}
+		if err := m.up(doc, info); err != nil {
+			return nil, from, fmt.Errorf("migration %d (%s): %w", m.from, m.name, err)
+		}
+	}
+	doc["schema_version"] = CurrentSchema
+	out, err := json.Marshal(doc)
+	return out, from, err
+}
+
+// snippetFields is Snippet without its JSON methods.
+type snippetFields Snippet
+
+// knownKeys lists the JSON keys of Snippet's own fields.
+var knownKeys = func() map[string]bool {
+	keys := map[string]bool{}
+	t := reflect.TypeOf(snippetFields{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}()
+
+// UnmarshalJSON decodes the known fields and keeps the others in Extra.
+func (s *Snippet) UnmarshalJSON(b []byte) error {
+	var f snippetFields
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return err
+	}
+	for k := range all {
+		if knownKeys[k] {
+			delete(all, k)
+		}
+	}
+	if len(all) == 0 {
+		all = nil
+	}
+	f.Extra = all
+	*s = Snippet(f)
+	return nil
+}
+
+// MarshalJSON encodes the known fields followed by the preserved unknown ones.
+func (s Snippet) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(snippetFields(s))
+	if err != nil || len(s.Extra) == 0 {
+		return b, err
+	}
+	keys := make([]string, 0, len(s.Extra))
+	for k := range s.Extra {
+		if !knownKeys[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	buf.Write(b[:len(b)-1])
+	for _, k := range keys {
+		kb, _ := json.Marshal(k)
+		buf.WriteByte(',')
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(s.Extra[k])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,48 @@
+package snippets
+
+import "strings"
+
+// TagQueryPrefix starts a search query filtering on one tag (and its children).
+const TagQueryPrefix = "#"
+
+// MatchQuery reports whether s matches a search query, ignoring case: "#tag"
+// keeps the snippets carrying the tag or one of its children, anything else is
+// looked up in the title, description, category, tags, aliases and, unless
+// encrypted, the content.
+func MatchQuery(s Snippet, q string) bool {
+	q = strings.TrimSpace(q)
+	if tag, ok := strings.CutPrefix(q, TagQueryPrefix); ok {
+		return HasTag(s.Tags, tag)
+	}
+	q = strings.ToLower(q)
+	if strings.Contains(strings.ToLower(s.Title), q) ||
+		strings.Contains(strings.ToLower(s.Description), q) ||
+		strings.Contains(strings.ToLower(s.Category), q) ||
+		(!s.Encrypted && strings.Contains(strings.ToLower(s.Content), q)) {
+		return true
+	}
+	for _, t := range append(append([]string(nil), s.Tags...), s.Aliases...) {
+		if strings.Contains(strings.ToLower(t), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the snippets of all matching q; an empty query matches all.
+// A snippet whose alias is exactly q comes first.
+func Search(all []Snippet, q string) []Snippet {
+	if strings.TrimSpace(q) == "" {
+		return all
+	}
+	var first, rest []Snippet
+	for _, s := range all {
+		switch {
+		case HasAlias(s, q):
+			first = append(first, s)
+		case MatchQuery(s, q):
+			rest = append(rest, s)
+		}
+	}
+	return append(first, rest...)
+}
@@ -0,0 +1,28 @@
+package snippets
+
+import "github.com/HrodWolfS/snipster/internal/secrets"
+
+// WhereDescription is the Finding.Where of secrets found in the description.
+const WhereDescription = "description"
+
+// ScanSecrets scans everything of s that is stored in clear: the main content,
+// each bundle file and the description. The vault only encrypts contents, so
+// the description of an encrypted snippet is still scanned.
+func ScanSecrets(s Snippet) []secrets.Finding {
+	var found []secrets.Finding
+	if !s.Encrypted {
+		found = secrets.Scan(s.Content)
+		for _, f := range s.Files {
+			found = appendWhere(found, secrets.Scan(f.Content), f.Name)
+		}
+	}
+	return appendWhere(found, secrets.Scan(s.Description), WhereDescription)
+}
+
+func appendWhere(out, found []secrets.Finding, where string) []secrets.Finding {
+	for _, f := range found {
+		f.Where = where
+		out = append(out, f)
+	}
+	return out
+}
@@ -1,17 +1,60 @@
 package snippets
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Snippet struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Category  string    `json:"category"`
-	Language  string    `json:"language"`
-	Tags      []string  `json:"tags"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	// SchemaVersion of the file this snippet was read from; see CurrentSchema.
+	SchemaVersion int       `json:"schema_version"`
+	ID            string    `json:"id"`
+	Title         string    `json:"title"`
+	Category      string    `json:"category"`
+	Language      string    `json:"language"`
+	Tags          []string  `json:"tags"`
+	Content       string    `json:"content"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// Description explains when to use the snippet and its caveats, in Markdown.
+	Description string `json:"description,omitempty"`
+	// Provenance of snippets copied from elsewhere (docs, Q&A sites…).
+	SourceURL string `json:"source_url,omitempty"`
+	Author    string `json:"author,omitempty"`
+	License   string `json:"license,omitempty"`
+	// Related lists the IDs of snippets that go with this one; descriptions
+	// can also link to snippets inline with [[id]].
+	Related []string `json:"related,omitempty"`
+	// Aliases are short names unique across the library, e.g. "dkp", that
+	// commands and the search bar accept in place of the ID.
+	Aliases []string `json:"aliases,omitempty"`
+	// Encrypted snippets store Content (and file contents) as vault ciphertext.
+	Encrypted bool `json:"encrypted,omitempty"`
+	// Files turns the snippet into a bundle of related files, in display order.
+	Files []File `json:"files,omitempty"`
+	// Remotes maps a remote provider name to the ID of the snippet there.
+	Remotes map[string]string `json:"remotes,omitempty"`
 
 	// Path on disk (not serialized)
 	Path string `json:"-"`
+	// Extra keeps fields unknown to this build, so files written by newer
+	// versions survive a round-trip.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// File is one named file of a multi-file snippet.
+type File struct {
+	Name     string `json:"name"`
+	Language string `json:"language,omitempty"`
+	Content  string `json:"content"`
+}
+
+// Parts returns the snippet as a list of files: the main content first (named
+// "main") when present, then the bundle files.
+func (s Snippet) Parts() []File {
+	var out []File
+	if s.Content != "" || len(s.Files) == 0 {
+		out = append(out, File{Name: "main", Language: s.Language, Content: s.Content})
+	}
+	return append(out, s.Files...)
 }
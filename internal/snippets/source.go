@@ -0,0 +1,19 @@
+package snippets
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ValidateSourceURL checks that u, when set, is an absolute http(s) URL.
+func ValidateSourceURL(u string) error {
+	if u = strings.TrimSpace(u); u == "" {
+		return nil
+	}
+	p, err := url.Parse(u)
+	if err != nil || (p.Scheme != "http" && p.Scheme != "https") || p.Host == "" {
+		return fmt.Errorf("source %q is not an http(s) URL", u)
+	}
+	return nil
+}
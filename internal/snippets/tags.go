@@ -0,0 +1,110 @@
+package snippets
+
+import (
+	"sort"
+	"strings"
+)
+
+// Tags may be hierarchical, "cloud/aws": a snippet tagged cloud/aws is also
+// found under cloud.
+
+// TagCount is one entry of the tag index.
+type TagCount struct {
+	Tag   string
+	Count int // snippets carrying the tag or one of its children
+	Depth int // number of "/" in Tag
+}
+
+// HasTag reports whether tags contain tag or one of its children, ignoring case.
+func HasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if tagUnder(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func tagUnder(t, tag string) bool {
+	t, tag = strings.ToLower(t), strings.ToLower(strings.Trim(tag, "/"))
+	return t == tag || strings.HasPrefix(t, tag+"/")
+}
+
+// TagIndex lists every tag and parent tag of all, sorted so that children
+// follow their parent. Tags differing only by case are counted together, under
+// the first spelling met.
+func TagIndex(all []Snippet) []TagCount {
+	counts := map[string]int{}
+	spelling := map[string]string{}
+	for _, s := range all {
+		seen := map[string]bool{}
+		for _, t := range s.Tags {
+			parts := strings.Split(strings.Trim(t, "/"), "/")
+			for i := range parts {
+				name := strings.Join(parts[:i+1], "/")
+				key := strings.ToLower(name)
+				if key == "" || seen[key] {
+					continue
+				}
+				seen[key] = true
+				counts[key]++
+				if _, ok := spelling[key]; !ok {
+					spelling[key] = name
+				}
+			}
+		}
+	}
+	out := make([]TagCount, 0, len(counts))
+	for key, n := range counts {
+		out = append(out, TagCount{Tag: spelling[key], Count: n, Depth: strings.Count(key, "/")})
+	}
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Tag) < strings.ToLower(out[j].Tag) })
+	return out
+}
+
+// KnownTags returns the distinct tags of all, as written, for autocompletion.
+func KnownTags(all []Snippet) []string {
+	var out []string
+	for _, tc := range TagIndex(all) {
+		out = append(out, tc.Tag)
+	}
+	return out
+}
+
+// RenameTag replaces tag from (and its children) by to in tags. When to is
+// already present the two are merged. ok is false when from was not found.
+func RenameTag(tags []string, from, to string) ([]string, bool) {
+	from, to = strings.Trim(from, "/"), strings.Trim(to, "/")
+	var out []string
+	seen := map[string]bool{}
+	found := false
+	for _, t := range tags {
+		if tagUnder(t, from) {
+			t = to + t[len(from):]
+			found = true
+		}
+		if k := strings.ToLower(t); !seen[k] && t != "" {
+			seen[k] = true
+			out = append(out, t)
+		}
+	}
+	return out, found
+}
+
+// RenameTag renames (or merges) a tag across the given snippets and returns how
+// many files were rewritten.
+func (r *Repo) RenameTag(all []Snippet, from, to string) (int, error) {
+	n := 0
+	for _, s := range all {
+		tags, ok := RenameTag(s.Tags, from, to)
+		if !ok {
+			continue
+		}
+		s.Tags = tags
+		if _, err := r.Update(s); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
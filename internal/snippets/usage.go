@@ -0,0 +1,150 @@
+package snippets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// UsageFile is where usage counts were kept at the library root before they
+// moved to the per-user state directory; it is still read when no per-user
+// counts exist yet.
+const UsageFile = ".usage.json"
+
+// How long RecordUse waits for another process to release the usage lock, and
+// the age after which a lock left by a crashed process is ignored.
+const (
+	usageLockWait  = time.Second
+	usageLockStale = 10 * time.Second
+)
+
+// Usage of one snippet.
+type Usage struct {
+	Count    int       `json:"count"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// usagePath returns the file counting how often the snippets of the library
+// were used (copied, picked, printed): <user cache dir>/snipster/usage-<hash of
+// the library path>.json. Counts are personal and change on every copy, so they
+// stay out of the library, which is often synced with git.
+func (r *Repo) usagePath() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	root, err := filepath.Abs(r.root)
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New32a()
+	h.Write([]byte(root))
+	return filepath.Join(base, "snipster", fmt.Sprintf("usage-%08x.json", h.Sum32())), nil
+}
+
+// LoadUsage returns the usage of every snippet used so far, by ID.
+func (r *Repo) LoadUsage() (map[string]Usage, error) {
+	out := map[string]Usage{}
+	path, err := r.usagePath()
+	if err != nil {
+		return out, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		b, err = os.ReadFile(filepath.Join(r.root, UsageFile))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return out, nil
+	}
+	if err != nil {
+		return out, err
+	}
+	return out, json.Unmarshal(b, &out)
+}
+
+// RecordUse counts one use of the snippet with the given ID. The TUI, snip serve
+// and the CLI may record at the same time: a lock file serializes them and the
+// counts are replaced atomically.
+func (r *Repo) RecordUse(id string) error {
+	if id == "" {
+		return nil
+	}
+	path, err := r.usagePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	usage, err := r.LoadUsage()
+	if err != nil {
+		// A damaged file only loses the counts.
+		usage = map[string]Usage{}
+	}
+	u := usage[id]
+	u.Count++
+	u.LastUsed = time.Now().UTC()
+	usage[id] = u
+	b, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(path, b)
+}
+
+// lockFile creates path exclusively, waiting a little for another holder, and
+// returns the function removing it.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(usageLockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > usageLockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// writeAtomic replaces path with data through a temporary file in the same
+// directory, so that readers never see a partial file.
+func writeAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
@@ -1,21 +1,35 @@
 package snippets
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 )
 
-// Create writes a new snippet JSON file based on category and id.
-func (r *Repo) Create(s Snippet) (Snippet, error) {
+// Create writes a new snippet file in the repo's default format. New snippets get
+// a NewID; the file is named after the title slug, with a numeric suffix when taken.
+// The category must stay inside the library (see ValidateCategory) and aliases
+// must be free (see CheckAliases).
+func (r *Repo) Create(s Snippet) (Snippet, error) { return r.CreateAmong(nil, s) }
+
+// CreateAmong is Create with the aliases of s checked against all, the library
+// as the caller already loaded it, instead of a new scan of the files. A nil
+// all scans them.
+func (r *Repo) CreateAmong(all []Snippet, s Snippet) (Snippet, error) {
+	if err := ValidateCategory(s.Category); err != nil {
+		return s, err
+	}
 	if s.ID == "" {
-		s.ID = Slugify(s.Title)
+		s.ID = NewID()
+	}
+	s.Aliases = NormalizeAliases(s.Aliases)
+	if err := r.checkAliases(all, s); err != nil {
+		return s, err
 	}
 	now := time.Now().UTC()
+	s.SchemaVersion = CurrentSchema
 	if s.CreatedAt.IsZero() {
 		s.CreatedAt = now
 	}
@@ -25,65 +39,190 @@ func (r *Repo) Create(s Snippet) (Snippet, error) {
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return s, err
 	}
-	path := filepath.Join(dir, s.ID+".json")
-	if _, err := os.Stat(path); err == nil {
-		return s, fmt.Errorf("snippet exists: %s", path)
-	}
-	if err := writeJSON(path, s); err != nil {
+	path := freePath(dir, Slugify(s.Title), r.format.Ext())
+	if err := writeSnippet(path, s); err != nil {
 		return s, err
 	}
 	s.Path = path
 	return s, nil
 }
 
-// Update overwrites an existing snippet JSON file.
-func (r *Repo) Update(s Snippet) (Snippet, error) {
+// Update overwrites an existing snippet file. The category and aliases are
+// checked as in Create.
+func (r *Repo) Update(s Snippet) (Snippet, error) { return r.UpdateAmong(nil, s) }
+
+// UpdateAmong is Update with the aliases checked against all, as in CreateAmong.
+func (r *Repo) UpdateAmong(all []Snippet, s Snippet) (Snippet, error) {
+	if err := ValidateCategory(s.Category); err != nil {
+		return s, err
+	}
 	if s.ID == "" {
-		s.ID = Slugify(s.Title)
+		s.ID = NewID()
+	}
+	s.Aliases = NormalizeAliases(s.Aliases)
+	if err := r.checkAliases(all, s); err != nil {
+		return s, err
 	}
 	s.UpdatedAt = time.Now().UTC()
+	// Snippets are migrated in memory on load; files from a newer schema keep their version.
+	if s.SchemaVersion < CurrentSchema {
+		s.SchemaVersion = CurrentSchema
+	}
 	// Respect existing path if provided; otherwise compute from category/id
 	path := s.Path
 	if path == "" {
-		dir := filepath.Join(r.root, filepath.FromSlash(s.Category))
-		path = filepath.Join(dir, s.ID+".json")
+		path = r.pathFor(s)
 	}
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return s, err
 	}
-	if err := writeJSON(path, s); err != nil {
+	if err := writeSnippet(path, s); err != nil {
 		return s, err
 	}
 	s.Path = path
 	return s, nil
 }
 
-// Delete removes the snippet JSON file.
+// Delete removes the snippet file.
 func (r *Repo) Delete(s Snippet) error {
 	path := s.Path
 	if path == "" {
-		dir := filepath.Join(r.root, filepath.FromSlash(s.Category))
-		path = filepath.Join(dir, s.ID+".json")
+		path = r.pathFor(s)
 	}
 	return os.Remove(path)
 }
 
-func writeJSON(path string, s Snippet) error {
-	b, err := json.MarshalIndent(s, "", "  ")
+// Move rewrites s under category, in the same format and with the same file
+// name when free, then removes the old file.
+func (r *Repo) Move(s Snippet, category string) (Snippet, error) {
+	if s.Path == "" {
+		return s, fmt.Errorf("snippet %s has no file", s.ID)
+	}
+	if err := ValidateCategory(category); err != nil {
+		return s, err
+	}
+	old := s.Path
+	s.Category = category
+	dir := filepath.Join(r.root, filepath.FromSlash(category))
+	if filepath.Dir(old) == dir {
+		return r.Update(s)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return s, err
+	}
+	s.Path = freePath(dir, s.Slug(), filepath.Ext(old))
+	s, err := r.Update(s)
 	if err != nil {
-		return err
+		return s, err
 	}
-	return os.WriteFile(path, b, 0o644)
+	return s, os.Remove(old)
+}
+
+// ExportFiles copies the files of the given snippets into dir, keeping their
+// category folders, and returns the number of files written.
+func (r *Repo) ExportFiles(list []Snippet, dir string) (int, error) {
+	n := 0
+	for _, s := range list {
+		rel, err := filepath.Rel(r.root, s.Path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = filepath.Join(filepath.FromSlash(s.Category), filepath.Base(s.Path))
+		}
+		b, err := os.ReadFile(s.Path)
+		if err != nil {
+			return n, err
+		}
+		dst := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return n, err
+		}
+		if err := os.WriteFile(dst, b, 0o644); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// TrashDir holds snippets removed by a merge, under the repo root. Hidden
+// directories are not loaded.
+const TrashDir = ".trash"
+
+// Trash moves the snippet file into TrashDir and returns its new path.
+func (r *Repo) Trash(s Snippet) (string, error) {
+	if s.Path == "" {
+		return "", fmt.Errorf("snippet %s has no file", s.ID)
+	}
+	dir := filepath.Join(r.root, TrashDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, time.Now().UTC().Format("20060102T150405")+"-"+filepath.Base(s.Path))
+	if err := os.Rename(s.Path, dst); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// Convert rewrites the snippet file in format f next to the original, then removes the original.
+func (r *Repo) Convert(s Snippet, f Format) (Snippet, error) {
+	if s.Path == "" {
+		return s, fmt.Errorf("snippet %s has no file", s.ID)
+	}
+	if cur, _ := formatOf(s.Path); cur == f {
+		return s, nil
+	}
+	path := strings.TrimSuffix(s.Path, filepath.Ext(s.Path)) + f.Ext()
+	if fileExists(path) {
+		return s, fmt.Errorf("snippet exists: %s", path)
+	}
+	if err := writeSnippet(path, s); err != nil {
+		return s, err
+	}
+	if err := os.Remove(s.Path); err != nil {
+		return s, err
+	}
+	s.Path = path
+	return s, nil
 }
 
-var slugRe = regexp.MustCompile(`[^a-z0-9]+`)
+// pathFor computes the default file path of a snippet from its category and slug.
+func (r *Repo) pathFor(s Snippet) string {
+	dir := filepath.Join(r.root, filepath.FromSlash(s.Category))
+	return filepath.Join(dir, s.Slug()+r.format.Ext())
+}
+
+// freePath returns dir/slug.ext, or dir/slug-N.ext for the first N where no
+// snippet file of either format exists.
+func freePath(dir, slug, ext string) string {
+	name := slug
+	for n := 2; ; n++ {
+		taken := false
+		for _, f := range []Format{FormatJSON, FormatMarkdown} {
+			if fileExists(filepath.Join(dir, name+f.Ext())) {
+				taken = true
+			}
+		}
+		if !taken {
+			return filepath.Join(dir, name+ext)
+		}
+		name = fmt.Sprintf("%s-%d", slug, n)
+	}
+}
 
-func Slugify(in string) string {
-	x := strings.ToLower(strings.TrimSpace(in))
-	x = slugRe.ReplaceAllString(x, "-")
-	x = strings.Trim(x, "-")
-	if x == "" {
-		x = fmt.Sprintf("snippet-%d", time.Now().Unix())
+// writeSnippet encodes s according to the extension of path.
+func writeSnippet(path string, s Snippet) error {
+	f, ok := formatOf(path)
+	if !ok {
+		f = FormatJSON
+	}
+	b, err := encode(f, s)
+	if err != nil {
+		return err
 	}
-	return x
+	return os.WriteFile(path, b, 0o644)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
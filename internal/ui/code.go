@@ -17,14 +17,68 @@ func RenderCode(s snippets.Snippet) string {
 // RenderCodeHighlighted applies a simple substring highlight for lines containing the query.
 // Highlighting is applied before keyword coloring for simplicity.
 func RenderCodeHighlighted(s snippets.Snippet, query string) string {
-	header := strings.Join([]string{
+	return renderHeader(s) + "\n" + renderBody(s.Content, s.Language, query)
+}
+
+// RenderBundle renders a multi-file snippet with a tab bar and the active file.
+// Single-file snippets render like RenderCodeHighlighted.
+func RenderBundle(s snippets.Snippet, active int, query string) string {
+	parts := s.Parts()
+	if len(parts) <= 1 {
+		return RenderCodeHighlighted(s, query)
+	}
+	if active < 0 || active >= len(parts) {
+		active = 0
+	}
+	tabs := make([]string, len(parts))
+	for i, p := range parts {
+		if i == active {
+			tabs[i] = Theme.Tab.Render("[" + p.Name + "]")
+		} else {
+			tabs[i] = Theme.Footer.Render(" " + p.Name + " ")
+		}
+	}
+	p := parts[active]
+	lang := p.Language
+	if lang == "" {
+		lang = s.Language
+	}
+	return renderHeader(s) + "\n" + strings.Join(tabs, " ") + "\n" + renderBody(p.Content, lang, query)
+}
+
+// renderHeader renders the title, metadata line and description of s.
+func renderHeader(s snippets.Snippet) string {
+	lines := []string{
 		Theme.PreviewTitle.Render(s.Title),
 		Theme.Status.Render(fmt.Sprintf("%s | %s | %s", s.Category, s.Language, strings.Join(s.Tags, ", "))),
-		"",
-	}, "\n")
+	}
+	if p := provenance(s); p != "" {
+		lines = append(lines, Theme.Footer.Render(p))
+	}
+	if strings.TrimSpace(s.Description) != "" {
+		lines = append(lines, "", RenderMarkdown(s.Description), "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// provenance describes where s comes from: "source: <url> · by <author> · <license>".
+func provenance(s snippets.Snippet) string {
+	var parts []string
+	if s.SourceURL != "" {
+		parts = append(parts, "source: "+s.SourceURL)
+	}
+	if s.Author != "" {
+		parts = append(parts, "by "+s.Author)
+	}
+	if s.License != "" {
+		parts = append(parts, s.License)
+	}
+	return strings.Join(parts, " · ")
+}
 
+func renderBody(content, lang, query string) string {
 	q := strings.ToLower(strings.TrimSpace(query))
-	lines := strings.Split(s.Content, "\n")
+	lines := strings.Split(content, "\n")
 	var b strings.Builder
 	for i, ln := range lines {
 		// Left gutter with 1-based line numbers and a subtle bar; add an arrow if the line matches.
@@ -37,40 +91,88 @@ func RenderCodeHighlighted(s snippets.Snippet, query string) string {
 		if q != "" {
 			ln = highlightContains(ln, q)
 		}
-		code := highlightLine(ln, s.Language)
+		code := highlightLine(ln, lang)
 		b.WriteString(gutter)
 		b.WriteString(code)
 		if i < len(lines)-1 {
 			b.WriteString("\n")
 		}
 	}
-	return header + b.String()
+	return b.String()
 }
 
 var (
-	reJS  = regexp.MustCompile(`\b(const|let|var|function|return|if|else|for|while|switch|case|break|await|async|new|class|try|catch|throw)\b`)
-	reGo  = regexp.MustCompile(`\b(func|package|import|return|if|else|for|range|switch|case|break|go|defer|type|struct|interface|map|chan|var|const)\b`)
-	reSQL = regexp.MustCompile(`\b(SELECT|FROM|WHERE|AND|OR|INSERT|INTO|VALUES|UPDATE|SET|DELETE|JOIN|LEFT|RIGHT|ON|GROUP|BY|ORDER|LIMIT)\b`)
+	reJS = regexp.MustCompile(`\b(const|let|var|function|return|if|else|for|while|switch|case|break|await|async|new|class|try|catch|throw)\b`)
+	reGo = regexp.MustCompile(`\b(func|package|import|return|if|else|for|range|switch|case|break|go|defer|type|struct|interface|map|chan|var|const)\b`)
+	// SQL keywords match in any case; the preview shows SQL upper-cased.
+	reSQL = regexp.MustCompile(`(?i)\b(SELECT|FROM|WHERE|AND|OR|INSERT|INTO|VALUES|UPDATE|SET|DELETE|JOIN|LEFT|RIGHT|ON|GROUP|BY|ORDER|LIMIT)\b`)
 	reTS  = reJS
 )
 
-func highlightLine(line, lang string) string {
-	styleKeyword := Theme.CodeKeyword
-	hi := func(re *regexp.Regexp, s string) string {
-		return re.ReplaceAllStringFunc(s, func(m string) string { return styleKeyword.Render(m) })
-	}
+// TokenKind classifies a run of code for coloring.
+type TokenKind int
+
+const (
+	TokenPlain TokenKind = iota
+	TokenKeyword
+)
+
+// Token is a run of a code line and its kind.
+type Token struct {
+	Text string
+	Kind TokenKind
+}
+
+func keywordRe(lang string) *regexp.Regexp {
 	switch strings.ToLower(lang) {
 	case "js", "javascript":
-		return hi(reJS, line)
+		return reJS
 	case "ts", "typescript":
-		return hi(reTS, line)
+		return reTS
 	case "go", "golang":
-		return hi(reGo, line)
+		return reGo
 	case "sql":
-		return hi(reSQL, strings.ToUpper(line))
+		return reSQL
 	default:
-		return line
+		return nil
+	}
+}
+
+// Tokenize splits a line of code in lang into plain and keyword runs. It is the
+// classification behind the preview coloring, shared by the HTML export.
+func Tokenize(line, lang string) []Token {
+	re := keywordRe(lang)
+	if re == nil {
+		return []Token{{Text: line}}
+	}
+	var out []Token
+	last := 0
+	for _, loc := range re.FindAllStringIndex(line, -1) {
+		if loc[0] > last {
+			out = append(out, Token{Text: line[last:loc[0]]})
+		}
+		out = append(out, Token{Text: line[loc[0]:loc[1]], Kind: TokenKeyword})
+		last = loc[1]
+	}
+	if last < len(line) || len(out) == 0 {
+		out = append(out, Token{Text: line[last:]})
 	}
+	return out
+}
+
+func highlightLine(line, lang string) string {
+	if strings.EqualFold(lang, "sql") {
+		line = strings.ToUpper(line)
+	}
+	var b strings.Builder
+	for _, t := range Tokenize(line, lang) {
+		if t.Kind == TokenKeyword {
+			b.WriteString(Theme.CodeKeyword.Render(t.Text))
+		} else {
+			b.WriteString(t.Text)
+		}
+	}
+	return b.String()
 }
 
 // highlightContains wraps occurrences of q (already lowercased) in a subtle accent color.
@@ -97,3 +199,34 @@ func highlightContains(line, q string) string {
 	}
 	return out.String()
 }
+
+// RenderRelated renders the links of a snippet under its code: the related
+// snippets, then the ones linking to it, numbered for quick jumps (1-9), and
+// the refs that match no snippet.
+func RenderRelated(related, backlinks []snippets.Snippet, dangling []string) string {
+	if len(related)+len(backlinks)+len(dangling) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	n := 0
+	section := func(title string, list []snippets.Snippet) {
+		if len(list) == 0 {
+			return
+		}
+		b.WriteString("\n" + Theme.PreviewTitle.Render(title) + "\n")
+		for _, s := range list {
+			n++
+			key := "   "
+			if n <= 9 {
+				key = fmt.Sprintf("%d ", n)
+			}
+			b.WriteString(Theme.CodeGutter.Render(key) + " " + s.Title + " " + Theme.Footer.Render(s.Category) + "\n")
+		}
+	}
+	section("Related", related)
+	section("Linked from", backlinks)
+	for _, ref := range dangling {
+		b.WriteString(Theme.ErrorText.Render("  ✗ missing "+ref) + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
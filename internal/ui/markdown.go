@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SpanKind classifies a run of Markdown text.
+type SpanKind int
+
+const (
+	SpanText SpanKind = iota
+	SpanEmphasis
+	SpanStrong
+	SpanCode
+	SpanLink
+	// SpanRef is a [[ref]] link to another snippet; Text is the ref.
+	SpanRef
+)
+
+// Span is a run of a Markdown line; URL is set for links.
+type Span struct {
+	Text string
+	Kind SpanKind
+	URL  string
+}
+
+// MarkdownLine is one line of a description: a list item when Bullet is set
+// ("•" or the item number), a blank line between paragraphs when it has no spans.
+type MarkdownLine struct {
+	Bullet string
+	Indent int
+	Spans  []Span
+}
+
+var (
+	reBullet  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	reNumber  = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	reHeading = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+	// Inline code first so that markers inside it stay literal.
+	reInline = regexp.MustCompile("`([^`]+)`" + `|\[\[([^\[\]\n]+)\]\]|\[([^\]]+)\]\(([^)\s]+)\)|\*\*([^*]+)\*\*|__([^_]+)__|\*([^*\s](?:[^*]*[^*\s])?)\*|\b_([^_\s](?:[^_]*[^_\s])?)_\b`)
+)
+
+// ParseMarkdown splits a description into lines of styled spans. Only the
+// basics are understood: emphasis, strong, inline code, links, lists and
+// headings (rendered strong). It is shared by the preview and the HTML export.
+func ParseMarkdown(text string) []MarkdownLine {
+	var out []MarkdownLine
+	blank := false
+	for _, ln := range strings.Split(strings.TrimSpace(text), "\n") {
+		if strings.TrimSpace(ln) == "" {
+			// Consecutive blank lines collapse into one paragraph break.
+			if !blank {
+				out = append(out, MarkdownLine{})
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		var ml MarkdownLine
+		if sub := reBullet.FindStringSubmatch(ln); sub != nil {
+			ml.Indent, ml.Bullet, ln = len(sub[1])/2, "•", sub[2]
+		} else if sub := reNumber.FindStringSubmatch(ln); sub != nil {
+			ml.Indent, ml.Bullet, ln = len(sub[1])/2, sub[2], sub[3]
+		} else if sub := reHeading.FindStringSubmatch(strings.TrimSpace(ln)); sub != nil {
+			ml.Spans = []Span{{Text: sub[1], Kind: SpanStrong}}
+			out = append(out, ml)
+			continue
+		} else {
+			ln = strings.TrimSpace(ln)
+		}
+		ml.Spans = ParseInline(ln)
+		out = append(out, ml)
+	}
+	return out
+}
+
+// ParseInline splits one line into text, emphasis, strong, code, link and
+// snippet reference spans.
+func ParseInline(line string) []Span {
+	var out []Span
+	last := 0
+	for _, m := range reInline.FindAllStringSubmatchIndex(line, -1) {
+		if m[0] > last {
+			out = append(out, Span{Text: line[last:m[0]]})
+		}
+		group := func(i int) string { return line[m[2*i]:m[2*i+1]] }
+		switch {
+		case m[2] >= 0:
+			out = append(out, Span{Text: group(1), Kind: SpanCode})
+		case m[4] >= 0:
+			out = append(out, Span{Text: strings.TrimSpace(group(2)), Kind: SpanRef})
+		case m[6] >= 0:
+			out = append(out, Span{Text: group(3), Kind: SpanLink, URL: group(4)})
+		case m[10] >= 0:
+			out = append(out, Span{Text: group(5), Kind: SpanStrong})
+		case m[12] >= 0:
+			out = append(out, Span{Text: group(6), Kind: SpanStrong})
+		case m[14] >= 0:
+			out = append(out, Span{Text: group(7), Kind: SpanEmphasis})
+		default:
+			out = append(out, Span{Text: group(8), Kind: SpanEmphasis})
+		}
+		last = m[1]
+	}
+	if last < len(line) {
+		out = append(out, Span{Text: line[last:]})
+	}
+	return out
+}
+
+// RenderMarkdown renders a description for the terminal with the theme styles.
+// [[ref]] links show as ref; callers swap refs for titles beforehand.
+func RenderMarkdown(text string) string {
+	lines := ParseMarkdown(text)
+	out := make([]string, len(lines))
+	for i, ml := range lines {
+		var b strings.Builder
+		if ml.Bullet != "" {
+			b.WriteString(strings.Repeat("  ", ml.Indent+1))
+			b.WriteString(Theme.MarkdownBullet.Render(ml.Bullet))
+			b.WriteString(" ")
+		}
+		for _, sp := range ml.Spans {
+			switch sp.Kind {
+			case SpanEmphasis:
+				b.WriteString(Theme.MarkdownEmphasis.Render(sp.Text))
+			case SpanStrong:
+				b.WriteString(Theme.MarkdownStrong.Render(sp.Text))
+			case SpanCode:
+				b.WriteString(Theme.MarkdownCode.Render(sp.Text))
+			case SpanRef:
+				b.WriteString(Theme.MarkdownLink.Render("↗ " + sp.Text))
+			case SpanLink:
+				b.WriteString(Theme.MarkdownLink.Render(sp.Text))
+				if sp.URL != sp.Text {
+					b.WriteString(Theme.Footer.Render(" (" + sp.URL + ")"))
+				}
+			default:
+				b.WriteString(sp.Text)
+			}
+		}
+		out[i] = b.String()
+	}
+	return strings.Join(out, "\n")
+}
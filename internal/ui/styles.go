@@ -37,6 +37,16 @@ type ThemeStyles struct {
 
 	// Highlight style for search matches
 	Match lipgloss.Style
+
+	// Active file tab of multi-file snippets
+	Tab lipgloss.Style
+
+	// Markdown of snippet descriptions
+	MarkdownEmphasis lipgloss.Style
+	MarkdownStrong   lipgloss.Style
+	MarkdownCode     lipgloss.Style
+	MarkdownLink     lipgloss.Style
+	MarkdownBullet   lipgloss.Style
 }
 
 func NewTheme() ThemeStyles {
@@ -86,6 +96,13 @@ func NewTheme() ThemeStyles {
 		CodeText:     lipgloss.NewStyle(),
 		CodeKeyword:  lipgloss.NewStyle().Foreground(accent2).Bold(true),
 		Match:        lipgloss.NewStyle().Foreground(accent2).Underline(true),
+		Tab:          lipgloss.NewStyle().Foreground(accent).Bold(true),
+
+		MarkdownEmphasis: lipgloss.NewStyle().Italic(true),
+		MarkdownStrong:   lipgloss.NewStyle().Bold(true),
+		MarkdownCode:     lipgloss.NewStyle().Foreground(accent2),
+		MarkdownLink:     lipgloss.NewStyle().Foreground(accent2).Underline(true),
+		MarkdownBullet:   lipgloss.NewStyle().Foreground(accent),
 	}
 }
 
@@ -0,0 +1,224 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/HrodWolfS/snipster/internal/snippets"
+)
+
+// FileName is the vault metadata file stored at the library root. It has no
+// .json suffix so that the snippet loader skips it.
+const FileName = ".vault"
+
+const (
+	kdfName       = "pbkdf2-sha256"
+	kdfIterations = 600_000
+	cipherPrefix  = "v1:"
+	// Known plaintext encrypted at creation, used to check the passphrase.
+	checkText = "snipster-vault"
+)
+
+var (
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	ErrCorrupt         = errors.New("invalid ciphertext")
+	ErrNoVault         = errors.New("no vault passphrase set yet")
+	ErrVaultExists     = errors.New("vault already exists")
+)
+
+type meta struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`
+	Check      string `json:"check"`
+}
+
+// Vault holds the key-derivation parameters shared by every encrypted snippet of a library.
+type Vault struct {
+	path string
+	meta *meta
+}
+
+// Open reads the vault metadata under root, if any.
+func Open(root string) (*Vault, error) {
+	v := &Vault{path: filepath.Join(root, FileName)}
+	b, err := os.ReadFile(v.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var md meta
+	if err := json.Unmarshal(b, &md); err != nil {
+		return nil, fmt.Errorf("read vault: %w", err)
+	}
+	if md.KDF != kdfName {
+		return nil, fmt.Errorf("unsupported vault kdf %q", md.KDF)
+	}
+	v.meta = &md
+	return v, nil
+}
+
+// Initialized reports whether a passphrase has already been set for this library.
+func (v *Vault) Initialized() bool { return v.meta != nil }
+
+// Unlock derives the key from passphrase. It fails with ErrNoVault until Create
+// has set the passphrase of the library.
+func (v *Vault) Unlock(passphrase string) (*Key, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	if v.meta == nil {
+		return nil, ErrNoVault
+	}
+	salt, err := base64.StdEncoding.DecodeString(v.meta.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("read vault salt: %w", err)
+	}
+	k, err := deriveKey(passphrase, salt, v.meta.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if got, err := k.Decrypt(v.meta.Check); err != nil || got != checkText {
+		return nil, ErrWrongPassphrase
+	}
+	return k, nil
+}
+
+// Create sets the passphrase of a library without a vault and returns its key.
+// Callers ask for the passphrase twice: a typo would make every snippet
+// encrypted afterwards unrecoverable.
+func (v *Vault) Create(passphrase string) (*Key, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	if v.meta != nil {
+		return nil, ErrVaultExists
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	k, err := deriveKey(passphrase, salt, kdfIterations)
+	if err != nil {
+		return nil, err
+	}
+	check, err := k.Encrypt(checkText)
+	if err != nil {
+		return nil, err
+	}
+	md := &meta{KDF: kdfName, Iterations: kdfIterations, Salt: base64.StdEncoding.EncodeToString(salt), Check: check}
+	b, err := json.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// O_EXCL: another process may have created the vault since Open.
+	f, err := os.OpenFile(v.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return nil, ErrVaultExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	v.meta = md
+	return k, nil
+}
+
+// Key encrypts and decrypts snippet contents with AES-256-GCM.
+type Key struct {
+	aead cipher.AEAD
+}
+
+func deriveKey(passphrase string, salt []byte, iter int) (*Key, error) {
+	raw, err := pbkdf2.Key(sha256.New, passphrase, salt, iter, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{aead: aead}, nil
+}
+
+// Encrypt returns "v1:" followed by base64(nonce|ciphertext).
+func (k *Key) Encrypt(plain string) (string, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := k.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return cipherPrefix + base64.StdEncoding.EncodeToString(out), nil
+}
+
+// Decrypt authenticates and decrypts a value produced by Encrypt.
+func (k *Key) Decrypt(ct string) (string, error) {
+	if !strings.HasPrefix(ct, cipherPrefix) {
+		return "", ErrCorrupt
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(ct, cipherPrefix))
+	if err != nil || len(b) < k.aead.NonceSize() {
+		return "", ErrCorrupt
+	}
+	n := k.aead.NonceSize()
+	plain, err := k.aead.Open(nil, b[:n], b[n:], nil)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(plain), nil
+}
+
+// EncryptSnippet returns s with its content and file contents encrypted.
+func EncryptSnippet(k *Key, s snippets.Snippet) (snippets.Snippet, error) {
+	return cryptSnippet(s, true, k.Encrypt)
+}
+
+// DecryptSnippet returns the plaintext version of an encrypted snippet.
+// Snippets that are not encrypted are returned as-is.
+func DecryptSnippet(k *Key, s snippets.Snippet) (snippets.Snippet, error) {
+	if !s.Encrypted {
+		return s, nil
+	}
+	return cryptSnippet(s, false, k.Decrypt)
+}
+
+func cryptSnippet(s snippets.Snippet, encrypted bool, fn func(string) (string, error)) (snippets.Snippet, error) {
+	var err error
+	if s.Content, err = fn(s.Content); err != nil {
+		return s, err
+	}
+	files := make([]snippets.File, len(s.Files))
+	for i, f := range s.Files {
+		if f.Content, err = fn(f.Content); err != nil {
+			return s, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		files[i] = f
+	}
+	if len(files) > 0 {
+		s.Files = files
+	}
+	s.Encrypted = encrypted
+	return s, nil
+}